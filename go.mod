@@ -2,7 +2,10 @@ module github.com/nishanths/predeclared
 
 go 1.22.0
 
-require golang.org/x/tools v0.30.0
+require (
+	golang.org/x/tools v0.30.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	golang.org/x/mod v0.23.0 // indirect
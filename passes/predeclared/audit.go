@@ -0,0 +1,268 @@
+package predeclared
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// auditDirectives reports the directives of cfg that suppress no report, with
+// fixes that delete them, and the directives that give no reason. The
+// directives in excluded files, which aren't checked, aren't audited.
+func auditDirectives(pass *analysis.Pass, cfg *config) {
+	s := cfg.directives
+	var all []*directive
+	for _, file := range pass.Files {
+		all = append(all, s.files[file]...)
+	}
+	all = append(all, s.pkg...)
+	for _, d := range all {
+		c := d.comment
+		if d.nolint && !nolintNamesPredeclared(c.Text) {
+			// The comment is for every linter, and isn't ours to audit.
+			continue
+		}
+		if cfg.excluded(pass.Fset.File(c.Pos()).Name()) {
+			continue
+		}
+		if !d.used {
+			diag := analysis.Diagnostic{
+				Pos:     c.Pos(),
+				End:     c.End(),
+				Message: "directive suppresses no report",
+			}
+			if edit, ok := deleteDirective(pass, d); ok {
+				fix := analysis.SuggestedFix{
+					Message:   "Delete stale directive",
+					TextEdits: []analysis.TextEdit{edit},
+				}
+				if !cfg.editsExcluded(pass.Fset, &fix) {
+					diag.SuggestedFixes = []analysis.SuggestedFix{fix}
+				}
+			}
+			pass.Report(diag)
+			continue
+		}
+		if d.reason == "" {
+			hint := `"-- reason"`
+			if d.nolint {
+				hint = `"// reason"`
+			}
+			pass.Report(analysis.Diagnostic{
+				Pos:     c.Pos(),
+				End:     c.End(),
+				Message: fmt.Sprintf("directive gives no reason for the suppression (add %s)", hint),
+			})
+		}
+	}
+}
+
+// nolintNamesPredeclared reports whether the //nolint comment text names the
+// predeclared linter, rather than applying to all linters.
+func nolintNamesPredeclared(text string) bool {
+	linters, ok := nolintLinters(text)
+	if !ok {
+		return false
+	}
+	for _, linter := range linters {
+		if linter == "predeclared" {
+			return true
+		}
+	}
+	return false
+}
+
+// nolintLinters returns the linters listed by the //nolint comment text, and
+// whether it lists any.
+func nolintLinters(text string) ([]string, bool) {
+	text = strings.TrimPrefix(text, "//")
+	text, _, _ = strings.Cut(text, "//")
+	text = strings.TrimSpace(text)
+	list, ok := strings.CutPrefix(text, "nolint:")
+	if !ok {
+		return nil, false
+	}
+	var linters []string
+	for _, linter := range strings.Split(list, ",") {
+		linters = append(linters, strings.TrimSpace(linter))
+	}
+	return linters, true
+}
+
+// deleteDirective returns an edit that deletes the directive d. A //nolint
+// comment that names other linters too is rewritten to name only those.
+func deleteDirective(pass *analysis.Pass, d *directive) (analysis.TextEdit, bool) {
+	c := d.comment
+	tf := pass.Fset.File(c.Pos())
+	if tf == nil {
+		return analysis.TextEdit{}, false
+	}
+	if d.nolint {
+		if linters, ok := nolintLinters(c.Text); ok && len(linters) > 1 {
+			var others []string
+			for _, linter := range linters {
+				if linter != "predeclared" {
+					others = append(others, linter)
+				}
+			}
+			text := "//nolint:" + strings.Join(others, ",")
+			if _, reason, ok := strings.Cut(c.Text[2:], "//"); ok {
+				text += " //" + reason
+			}
+			return analysis.TextEdit{Pos: c.Pos(), End: c.End(), NewText: []byte(text)}, true
+		}
+	}
+	src, err := pass.ReadFile(tf.Name())
+	if err != nil {
+		return analysis.TextEdit{}, false
+	}
+	start, end := tf.Offset(c.Pos()), tf.Offset(c.End())
+	if d.trailing {
+		// Delete the space between the code and the comment too.
+		for start > 0 && (src[start-1] == ' ' || src[start-1] == '\t') {
+			start--
+		}
+		return analysis.TextEdit{Pos: tf.Pos(start), End: tf.Pos(end)}, true
+	}
+	// Delete the whole line, and a preceding empty "//" line that separated
+	// the directive from the rest of a doc comment.
+	line := tf.Line(c.Pos())
+	start = tf.Offset(tf.LineStart(line))
+	if list := d.group.List; len(list) > 1 && list[len(list)-1] == c {
+		if prev := list[len(list)-2]; prev.Text == "//" && tf.Line(prev.Pos()) == line-1 {
+			start = tf.Offset(tf.LineStart(line - 1))
+		}
+	}
+	if line < tf.LineCount() {
+		end = tf.Offset(tf.LineStart(line + 1))
+	} else if end < len(src) && src[end] == '\n' {
+		end++
+	}
+	return analysis.TextEdit{Pos: tf.Pos(start), End: tf.Pos(end)}, true
+}
+
+// auditIgnoreEntries reports the ignore entries of the configuration file in
+// the package's directory, if any, that suppress no report in any package
+// under that directory. Entries are reported at the package clause, as a
+// configuration file has no position in the package.
+//
+// Whether an entry suppresses a report in the package is known from its
+// check with cfg, which recorded the entries that matched. The other packages
+// are checked anew, only if needed, with the settings of c.
+func auditIgnoreEntries(pass *analysis.Pass, c Config, cfg *config, dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	p, cf, err := readConfigFile(dir)
+	if err != nil || cf == nil || len(cf.Ignore) == 0 {
+		return err
+	}
+	keys := make(map[string]string)
+	var stale []string
+	for _, entry := range cf.Ignore {
+		key, err := normalizeEntry(entry)
+		if err != nil {
+			return fmt.Errorf("%s: %s", p, err)
+		}
+		keys[entry] = key
+		if !cfg.usedIgnores[key] {
+			stale = append(stale, entry)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	used, err := usedIgnoreEntries(c, cfg.goVersion, p, dir, stale)
+	if err != nil {
+		return err
+	}
+	for _, entry := range stale {
+		if !used[keys[entry]] {
+			pass.Reportf(pass.Files[0].Name.Pos(), "ignore entry %q of %s suppresses no report", entry, p)
+		}
+	}
+	return nil
+}
+
+// usedIgnoreEntries returns the normalized forms of those of the ignore
+// entries of the configuration file at p that suppress a report in the
+// packages under the file's directory where the entries are in effect, other
+// than the package in skip. As it works from syntax alone, it treats the
+// files of every package as though they were checked without type
+// information, which errs towards entries being used. Directives aren't
+// applied: where a directive and an entry suppress the same report, the
+// directive is the one that suppresses no report.
+func usedIgnoreEntries(c Config, goVersion, p, skip string, entries []string) (map[string]bool, error) {
+	used := make(map[string]bool)
+	c.Ignore = nil
+	c.Audit = false
+	c.PrintConfig = false
+	// Precise mode needs the type information that's missing here.
+	c.Precise = false
+	err := filepath.WalkDir(filepath.Dir(p), func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !e.IsDir() {
+			return nil
+		}
+		// Skip the directories that the go command ignores.
+		if name := e.Name(); path != filepath.Dir(p) && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			return filepath.SkipDir
+		}
+		if path == skip {
+			return nil // the package's own check already counted
+		}
+		s, err := loadFileSettings(path)
+		if err != nil {
+			return err
+		}
+		if s == nil || s.ignoreFrom != p {
+			return nil // the entries aren't in effect here
+		}
+		unignored := *s
+		unignored.ignore = nil
+		cfg, err := newConfig(c, &unignored)
+		if err != nil {
+			return err
+		}
+		cfg.goVersion = goVersion
+		cfg.usedIgnores = used
+		cfg.ignoredIdents = identSet{}
+		for _, entry := range entries {
+			if err := cfg.ignoredIdents.add(entry); err != nil {
+				return fmt.Errorf("%s: %s", p, err)
+			}
+		}
+		fset := token.NewFileSet()
+		var files []*ast.File
+		names, err := filepath.Glob(filepath.Join(path, "*.go"))
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if file, err := parser.ParseFile(fset, name, nil, parser.ParseComments); err == nil {
+				files = append(files, file)
+			}
+		}
+		cfg.directives = new(directiveSet)
+		cfg.noteGenerated(fset, files)
+		cfg.setFiles(fset, files)
+		for _, file := range files {
+			processFile(func(analysis.Diagnostic) {}, cfg, fset, file, nil)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	return used, err
+}
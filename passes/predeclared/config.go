@@ -0,0 +1,565 @@
+package predeclared
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Config configures an analyzer created by NewAnalyzer. The zero Config
+// corresponds to the default behavior of Analyzer.
+type Config struct {
+	// Name is the name of the analyzer, "predeclared" if empty. Analyzers
+	// that are run by the same driver program, such as multichecker, must
+	// have distinct names, which also prefix their flags.
+	Name string
+	// Ignore lists predeclared identifiers to not report on. Each entry is an
+	// identifier, or a group of identifiers: @types, @constants, @zero (nil)
+	// or @builtins (builtin functions). An entry may be qualified by a kind of
+	// declaration, as in "param:new" or "receiver:@types", to apply to
+	// declarations of that kind only.
+	Ignore []string
+	// Only, if non-empty, lists the only predeclared identifiers to report
+	// on, in the form of Ignore entries.
+	Only []string
+	// Qualified includes method names and field names, including embedded
+	// fields (i.e., qualified names), in checks.
+	Qualified bool
+	// Precise uses type information to report only declarations that hide a
+	// universe-scope object.
+	Precise bool
+	// Upgrade, if set, is a Go version such as "1.21". Only declarations that
+	// will shadow a predeclared identifier once the module's go version is
+	// raised to that version are reported.
+	Upgrade string
+	// Renames maps predeclared identifiers, optionally qualified by a
+	// declaration kind as in "param:new", to the names that suggested fixes
+	// should rename declarations to.
+	Renames map[string]string
+	// RenameFile names a file of further renames, one [kind:]ident=name entry
+	// per line. Entries in Renames take precedence.
+	RenameFile string
+	// FixPackageNames enables suggested fixes that rename package clauses,
+	// and the references to them in importing packages of the same module.
+	FixPackageNames bool
+	// DryRun replaces each suggested fix with a description of the files that
+	// it would edit.
+	DryRun bool
+	// NoConfigFile disables the use of .predeclared.{yaml,json} configuration
+	// files. Otherwise the settings of the files found by walking up from a
+	// package's directory apply in addition to those of the Config.
+	NoConfigFile bool
+	// Enable and Disable list kinds of declarations to report, or not, in
+	// addition to or instead of the default kinds. Field, Method and
+	// EmbeddedField are reported by default only if Qualified is set. A kind
+	// may not be listed in both.
+	Enable, Disable []Kind
+	// Exclude lists patterns of files not to check, and not to edit with
+	// suggested fixes. Each is a glob, in which "**" matches any number of
+	// path elements, or a regular expression prefixed with "re:". Patterns
+	// are matched against slash-separated paths relative to the working
+	// directory.
+	Exclude []string
+	// IncludeGenerated checks generated files, which have a "Code generated
+	// ... DO NOT EDIT." comment, and lets fixes edit them.
+	IncludeGenerated bool
+	// IncludeVendored checks the files of vendored packages, whose import
+	// path has a "vendor" element, and lets fixes edit them.
+	IncludeVendored bool
+	// IncludePolyfills reports polyfills: package-level declarations of
+	// predeclared identifiers, such as min or any, that build constraints
+	// restrict to the Go versions before the identifier was added (e.g.
+	// "//go:build !go1.21"), and that are compatible with the identifier.
+	IncludePolyfills bool
+	// SkipBodiless skips the parameters and named results of functions
+	// without a body, such as those implemented in assembly, of function
+	// types, and of interface methods. Their names serve only as
+	// documentation.
+	SkipBodiless bool
+	// ExportedParams is the policy for the parameters and named results of
+	// exported API: of the package-level functions and methods with exported
+	// names, and of the methods of exported interface types and exported
+	// function types, outside of main packages and test files. It is
+	// "report", the default, to report them as any other; "skip" to skip
+	// them; or "separate" to report them as declarations of kind
+	// ExportedParam, which can be enabled, disabled and ignored on their own.
+	ExportedParams string
+	// ReferencedParams reports parameters and named results only if they're
+	// referenced in the body of their function. It needs type information,
+	// and is ignored by Check.
+	ReferencedParams bool
+	// MinRisk, if positive, is the least risk score, from 0 to 100, of the
+	// declarations to report, and reports then give their scores. A score
+	// weighs whether the function that holds the declaration, or else the
+	// package, uses the shadowed universe object; how many references the
+	// declaration has; and how big its scope is. MinRisk needs type
+	// information, and is ignored by Check.
+	MinRisk int
+	// Severity, if set, is the severity of every report. Otherwise the
+	// severity of a report is that of the class of the universe object that
+	// the declaration shadows.
+	Severity Severity
+	// Severities overrides the default severities of classes.
+	Severities map[Class]Severity
+	// Tests, ExternalTests and Examples amend the settings for test code:
+	// Tests for _test.go files; ExternalTests, on top of Tests, for the
+	// files of external test packages (such as package fmt_test); and
+	// Examples, on top of either, for the Example, Benchmark and Fuzz
+	// functions of test files.
+	Tests, ExternalTests, Examples Policy
+	// Audit additionally reports suppression directives that suppress no
+	// report, with fixes that delete them, and directives that give no
+	// reason. It also reports the ignore entries of the configuration file in
+	// a package's directory that suppress no report under that directory.
+	Audit bool
+	// PrintConfig prints the effective configuration for each package to
+	// standard output instead of checking the package.
+	PrintConfig bool
+}
+
+// registerFlags registers flags that set the fields of c in fs.
+func (c *Config) registerFlags(fs *flag.FlagSet) {
+	fs.Var((*listFlag)(&c.Ignore), IgnoreFlag, "comma-separated list of [kind:]ident or [kind:]@group entries to not report on (e.g. new,param:len,@constants); groups are @types, @constants, @zero and @builtins")
+	fs.Var((*listFlag)(&c.Only), OnlyFlag, "comma-separated list of [kind:]ident or [kind:]@group entries to report on, and no others")
+	fs.BoolVar(&c.Qualified, QualifiedFlag, c.Qualified, "include method names and field names (i.e., qualified names) in checks")
+	fs.BoolVar(&c.Precise, PreciseFlag, c.Precise, "use type information to report only declarations that hide a universe-scope object")
+	fs.StringVar(&c.Upgrade, UpgradeFlag, c.Upgrade, "report only declarations that will shadow a predeclared identifier once the module's go version is raised to the given version (e.g. 1.21)")
+	fs.Var((*mapFlag)(&c.Renames), RenameFlag, "comma-separated list of [kind:]ident=name replacements for suggested fixes to use (e.g. new=created,param:len=length)")
+	fs.StringVar(&c.RenameFile, RenameFileFlag, c.RenameFile, "file of [kind:]ident=name replacements, one per line, for suggested fixes to use")
+	fs.BoolVar(&c.FixPackageNames, FixPackagesFlag, c.FixPackageNames, "suggest fixes that rename package clauses, and the references to them in importing packages of the same module")
+	fs.BoolVar(&c.DryRun, DryRunFlag, c.DryRun, "describe the files that each suggested fix would edit instead of suggesting it")
+	fs.Var((*kindListFlag)(&c.Enable), EnableFlag, "comma-separated list of kinds of declarations to report, in addition to the default kinds (e.g. field,embedded-field)")
+	fs.Var((*kindListFlag)(&c.Disable), DisableFlag, "comma-separated list of kinds of declarations to not report (e.g. func-type-param,interface-method-param)")
+	fs.Var((*listFlag)(&c.Exclude), ExcludeFlag, "comma-separated list of glob patterns (with ** for any number of directories), or regular expressions prefixed with re:, of files not to check or edit (e.g. **/testdata/**,internal/legacy/**)")
+	fs.BoolVar(&c.IncludeGenerated, IncludeGeneratedFlag, c.IncludeGenerated, "check generated files, and let fixes edit them")
+	fs.BoolVar(&c.IncludeVendored, IncludeVendoredFlag, c.IncludeVendored, "check the files of vendored packages, and let fixes edit them")
+	fs.BoolVar(&c.IncludePolyfills, IncludePolyfillsFlag, c.IncludePolyfills, "report polyfills of newer predeclared identifiers (e.g. func min in a !go1.21 file)")
+	fs.BoolVar(&c.SkipBodiless, SkipBodilessFlag, c.SkipBodiless, "skip the params and named returns of functions without a body, function types and interface methods")
+	fs.StringVar(&c.ExportedParams, ExportedParamsFlag, c.ExportedParams, "policy for the params and named returns of exported API: report, skip, or separate (report them as kind exported-param)")
+	fs.BoolVar(&c.ReferencedParams, ReferencedParamsFlag, c.ReferencedParams, "report params and named returns only if they're referenced in the function body")
+	fs.IntVar(&c.MinRisk, MinRiskFlag, c.MinRisk, "report only declarations with at least this risk score, from 0 to 100, based on the use of the shadowed identifier nearby, the declaration's references and the size of its scope")
+	fs.Var((*severityFlag)(&c.Severity), SeverityFlag, "severity of every report (info, warning or error), instead of the severity of the class of the shadowed identifier")
+	fs.Var((*severityMapFlag)(&c.Severities), SeveritiesFlag, "comma-separated list of class=severity entries that override the default severities (e.g. builtin-function=error,basic-type=info); classes are zero-value, bool-constant, iota, basic-type, interface-type and builtin-function")
+	c.Tests.registerFlags(fs, TestFlagPrefix, "_test.go files")
+	c.ExternalTests.registerFlags(fs, ExternalTestFlagPrefix, "external test packages")
+	c.Examples.registerFlags(fs, ExampleFlagPrefix, "Example, Benchmark and Fuzz functions")
+	fs.BoolVar(&c.Audit, AuditFlag, c.Audit, "also report stale or unjustified suppression directives, and stale ignore entries of configuration files")
+	fs.BoolVar(&c.NoConfigFile, NoConfigFlag, c.NoConfigFile, "don't use .predeclared.{yaml,json} configuration files")
+	fs.BoolVar(&c.PrintConfig, PrintConfigFlag, c.PrintConfig, "print the effective configuration for each package instead of checking it")
+}
+
+// listFlag is a flag.Value for a comma-separated list of strings.
+type listFlag []string
+
+func (f *listFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *listFlag) Set(s string) error {
+	*f = nil
+	for _, elem := range strings.Split(s, ",") {
+		if elem = strings.TrimSpace(elem); elem != "" {
+			*f = append(*f, elem)
+		}
+	}
+	return nil
+}
+
+// mapFlag is a flag.Value for a comma-separated list of key=value pairs.
+type mapFlag map[string]string
+
+func (f *mapFlag) String() string {
+	var pairs []string
+	for k, v := range *f {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (f *mapFlag) Set(s string) error {
+	m := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid entry %q: want key=value", pair)
+		}
+		m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	*f = m
+	return nil
+}
+
+// kindListFlag is a flag.Value for a comma-separated list of kinds, written
+// in the forms accepted by ParseKinds.
+type kindListFlag []Kind
+
+func (f *kindListFlag) String() string {
+	var keys []string
+	for _, k := range *f {
+		keys = append(keys, k.key())
+	}
+	return strings.Join(keys, ",")
+}
+
+func (f *kindListFlag) Set(s string) error {
+	var kinds []Kind
+	for _, elem := range strings.Split(s, ",") {
+		if elem = strings.TrimSpace(elem); elem == "" {
+			continue
+		}
+		_, ks, err := parseKindKey(elem)
+		if err != nil {
+			return err
+		}
+		kinds = append(kinds, ks...)
+	}
+	*f = kinds
+	return nil
+}
+
+// config is the form of Config used while checking.
+type config struct {
+	// policy is the policy for production code. Its methods, such as
+	// selected and enabled, apply to the package as a whole.
+	policy
+	// codePolicies holds the policy for each kind of code, and
+	// examplePolicies the policy for the Example, Benchmark and Fuzz
+	// functions in each kind of test code.
+	codePolicies    [3]*policy
+	examplePolicies [3]*policy
+
+	precise bool
+	renames renameMap
+
+	// skipBodiless, exportedParams and referencedParams are the policy for
+	// parameters and named results. exportedParams is one of "report",
+	// "skip" or "separate".
+	skipBodiless     bool
+	exportedParams   string
+	referencedParams bool
+
+	// severities holds the severity of each class, for reports whose policy
+	// sets no severity.
+	severities map[Class]Severity
+	// minRisk is the least risk score of the declarations to report.
+	minRisk int
+
+	// goVersion is the Go version of the module being checked, e.g.
+	// "go1.21.0", or empty if unknown.
+	goVersion string
+	// upgradeVersion, if set, is the Go version that the module is to be
+	// upgraded to. Only declarations that will start shadowing a predeclared
+	// identifier in that version are reported.
+	upgradeVersion string
+
+	// fixPackageNames enables fixes that rename package clauses, which span
+	// packages.
+	fixPackageNames bool
+	// dryRun replaces each suggested fix with a description of the files it
+	// would edit.
+	dryRun bool
+
+	// excludes are the patterns of files not to check of configuration files,
+	// and pathExcludes those of Config.Exclude.
+	excludes     []excludePattern
+	pathExcludes []pathPattern
+	// generated holds the names of the generated files of the package being
+	// checked, unless includeGenerated is set.
+	generated        map[string]bool
+	includeGenerated bool
+	// vendored is whether the package being checked is vendored, which
+	// excludes its files unless includeVendored is set.
+	vendored         bool
+	includeVendored  bool
+	includePolyfills bool
+	// directives are the suppression directives in the files of the package
+	// being checked. If nil, each file's own directives apply to it.
+	directives *directiveSet
+	// files are the files of the package being checked. If nil, each file
+	// is checked as though it were the only file of its package.
+	files []*ast.File
+	// clauses holds, for each package name, the files of files whose
+	// package clauses are reported together, as clauseFiles returns them.
+	clauses map[string][]*ast.File
+	// configFiles are the configuration files that the config was read from.
+	configFiles []string
+	printConfig bool
+	audit       bool
+	// usedIgnores are the normalized ignore entries that excluded some
+	// declaration that would otherwise be reported. It's nil unless
+	// auditing.
+	usedIgnores map[string]bool
+}
+
+// loadConfig returns the config for the package in dir: the settings of c,
+// in addition to those of the configuration files that apply to dir unless c
+// disables them. dir may be empty if unknown.
+func loadConfig(c Config, dir string) (*config, error) {
+	if c.NoConfigFile || dir == "" {
+		return newConfig(c, nil)
+	}
+	s, err := loadFileSettings(dir)
+	if err != nil {
+		return nil, err
+	}
+	return newConfig(c, s)
+}
+
+// newConfig validates c and returns the corresponding config. The settings
+// of c are applied on top of s, which may be nil: ignored identifiers are
+// combined, qualified mode is enabled if either enables it, and the kinds
+// that c enables or disables, and its severity, override those of s. The
+// policies for test code are derived in the same way.
+func newConfig(c Config, s *fileSettings) (*config, error) {
+	cfg := &config{
+		policy: policy{
+			qualified:     c.Qualified,
+			ignoredIdents: identSet{},
+			onlyIdents:    identSet{},
+			severity:      c.Severity,
+		},
+		precise:          c.Precise,
+		renames:          renameMap{},
+		skipBodiless:     c.SkipBodiless,
+		exportedParams:   c.ExportedParams,
+		referencedParams: c.ReferencedParams,
+		severities:       make(map[Class]Severity),
+		minRisk:          c.MinRisk,
+		fixPackageNames:  c.FixPackageNames,
+		dryRun:           c.DryRun,
+		printConfig:      c.PrintConfig,
+		audit:            c.Audit,
+		generated:        make(map[string]bool),
+		includeGenerated: c.IncludeGenerated,
+		includeVendored:  c.IncludeVendored,
+		includePolyfills: c.IncludePolyfills,
+	}
+	if c.Audit {
+		cfg.usedIgnores = make(map[string]bool)
+	}
+	ignore, only := c.Ignore, c.Only
+	if s != nil {
+		cfg.qualified = cfg.qualified || s.qualified
+		if cfg.severity == 0 {
+			cfg.severity = s.severity
+		}
+		cfg.skipBodiless = cfg.skipBodiless || s.skipBodiless
+		cfg.referencedParams = cfg.referencedParams || s.referencedParams
+		if cfg.exportedParams == "" {
+			cfg.exportedParams = s.exportedParams
+		}
+		for class, sev := range s.severities {
+			cfg.severities[class] = sev
+		}
+		cfg.excludes = s.excludes
+		cfg.configFiles = s.files
+		ignore = append(append([]string(nil), s.ignore...), c.Ignore...)
+		if len(only) == 0 {
+			only = s.only
+		}
+	}
+	for _, entry := range ignore {
+		if err := cfg.ignoredIdents.add(entry); err != nil {
+			return nil, fmt.Errorf("-%s: %s", IgnoreFlag, err)
+		}
+	}
+	for _, entry := range only {
+		if err := cfg.onlyIdents.add(entry); err != nil {
+			return nil, fmt.Errorf("-%s: %s", OnlyFlag, err)
+		}
+	}
+	cfg.kinds = make(map[Kind]bool)
+	if s != nil {
+		for k, enabled := range s.kinds {
+			cfg.kinds[k] = enabled
+		}
+	}
+	for _, k := range c.Enable {
+		cfg.kinds[k] = true
+	}
+	for _, k := range c.Disable {
+		if containsKind(c.Enable, k) {
+			return nil, fmt.Errorf("kind %s is both enabled and disabled", k.key())
+		}
+		cfg.kinds[k] = false
+	}
+	for _, c := range classes {
+		if _, ok := cfg.severities[c.class]; !ok {
+			cfg.severities[c.class] = c.severity
+		}
+	}
+	for class, sev := range c.Severities {
+		if _, err := parseClass(string(class)); err != nil {
+			return nil, fmt.Errorf("-%s: %v", SeveritiesFlag, err)
+		}
+		cfg.severities[class] = sev
+	}
+	switch cfg.exportedParams {
+	case "":
+		cfg.exportedParams = "report"
+	case "report", "skip", "separate":
+	default:
+		return nil, fmt.Errorf("invalid -%s policy %q (want report, skip or separate)", ExportedParamsFlag, cfg.exportedParams)
+	}
+	if err := cfg.derivePolicies(c, s); err != nil {
+		return nil, err
+	}
+	for _, s := range c.Exclude {
+		p, err := parsePathPattern(s)
+		if err != nil {
+			return nil, err
+		}
+		cfg.pathExcludes = append(cfg.pathExcludes, p)
+	}
+	if c.Upgrade != "" {
+		cfg.upgradeVersion = normalizeGoVersion(c.Upgrade)
+		if cfg.upgradeVersion == "" {
+			return nil, fmt.Errorf("invalid -%s version %q", UpgradeFlag, c.Upgrade)
+		}
+	}
+	if c.RenameFile != "" {
+		if err := cfg.renames.parseFile(c.RenameFile); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range c.Renames {
+		if err := cfg.renames.parseEntry(k + "=" + v); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// derivePolicies derives the policies for test code from c.policy, amended
+// by the settings of s, which may be nil, and then by those of the Config.
+func (c *config) derivePolicies(conf Config, s *fileSettings) error {
+	var fileTests, fileXTests, fileExamples policySettings
+	if s != nil {
+		fileTests, fileXTests, fileExamples = s.tests, s.xtests, s.examples
+	}
+	tests, err := conf.Tests.settings(TestFlagPrefix)
+	if err != nil {
+		return err
+	}
+	xtests, err := conf.ExternalTests.settings(ExternalTestFlagPrefix)
+	if err != nil {
+		return err
+	}
+	examples, err := conf.Examples.settings(ExampleFlagPrefix)
+	if err != nil {
+		return err
+	}
+	c.codePolicies[productionCode] = &c.policy
+	if c.codePolicies[testCode], err = c.policy.amend(TestFlagPrefix, fileTests, tests); err != nil {
+		return err
+	}
+	if c.codePolicies[externalTestCode], err = c.codePolicies[testCode].amend(ExternalTestFlagPrefix, fileXTests, xtests); err != nil {
+		return err
+	}
+	for _, code := range []codeKind{testCode, externalTestCode} {
+		if c.examplePolicies[code], err = c.codePolicies[code].amend(ExampleFlagPrefix, fileExamples, examples); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsKind(kinds []Kind, k Kind) bool {
+	for _, x := range kinds {
+		if x == k {
+			return true
+		}
+	}
+	return false
+}
+
+// excluded reports whether the file with the given name is excluded from
+// checks and from the edits of fixes.
+func (c *config) excluded(filename string) bool {
+	if c.generated[filename] || !c.includeVendored && c.vendored {
+		return true
+	}
+	for _, e := range c.excludes {
+		if e.matches(filename) {
+			return true
+		}
+	}
+	for _, p := range c.pathExcludes {
+		if p.matches(filename) {
+			return true
+		}
+	}
+	return false
+}
+
+// editsExcluded reports whether fix edits a file that is excluded. Such a fix
+// isn't suggested: a fix can't leave out the edits of an excluded file, as
+// the rename that it makes would then be incomplete.
+func (c *config) editsExcluded(fset *token.FileSet, fix *analysis.SuggestedFix) bool {
+	for _, edit := range fix.TextEdits {
+		if c.excluded(fset.File(edit.Pos).Name()) {
+			return true
+		}
+	}
+	return false
+}
+
+// noteGenerated records which of files are generated, unless generated files
+// are included.
+func (c *config) noteGenerated(fset *token.FileSet, files []*ast.File) {
+	if c.includeGenerated {
+		return
+	}
+	for _, file := range files {
+		if ast.IsGenerated(file) {
+			c.generated[fset.File(file.Package).Name()] = true
+		}
+	}
+}
+
+// setFiles records files as the files of the package being checked, and
+// orders the files of each package name for clauseFiles. It must be called
+// after generated files are noted.
+func (c *config) setFiles(fset *token.FileSet, files []*ast.File) {
+	c.files = files
+	c.clauses = make(map[string][]*ast.File)
+	filenames := make(map[*ast.File]string)
+	excluded := make(map[*ast.File]bool)
+	for _, f := range files {
+		c.clauses[f.Name.Name] = append(c.clauses[f.Name.Name], f)
+		filenames[f] = fset.File(f.Package).Name()
+		excluded[f] = c.excluded(filenames[f])
+	}
+	for _, clauses := range c.clauses {
+		sort.SliceStable(clauses, func(i, j int) bool {
+			fi, fj := clauses[i], clauses[j]
+			if ei, ej := excluded[fi], excluded[fj]; ei != ej {
+				return ej
+			}
+			if di, dj := fi.Doc != nil, fj.Doc != nil; di != dj {
+				return di
+			}
+			return filenames[fi] < filenames[fj]
+		})
+	}
+}
+
+// clauseFiles returns the files of the package of file, which is being
+// checked, whose package clauses are reported together. The first is the
+// file that the package clause is reported at: the file that holds the
+// package doc comment, or else the first file in order of name, of the files
+// that aren't excluded. If the files of the package are unknown, it returns
+// file alone.
+func (c *config) clauseFiles(file *ast.File) []*ast.File {
+	if files := c.clauses[file.Name.Name]; len(files) > 0 {
+		return files
+	}
+	return []*ast.File{file}
+}
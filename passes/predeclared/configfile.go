@@ -0,0 +1,445 @@
+package predeclared
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFileNames are the names of configuration files. A directory may hold
+// at most one of them.
+var configFileNames = []string{".predeclared.yaml", ".predeclared.yml", ".predeclared.json"}
+
+// A configFile is the contents of a configuration file, for example:
+//
+//	preset: lenient
+//	ignore: [new, len]
+//	qualified: false
+//	kinds:
+//	  param: true
+//	exclude: [legacy, "*_gen.go"]
+//	tests:
+//	  ignore: [new, copy]
+//	  severity: warning
+type configFile struct {
+	// Root stops the search for configuration files in farther directories.
+	Root bool `yaml:"root,omitempty" json:"root,omitempty"`
+	// Preset names a preset whose settings the rest of the file amends.
+	Preset string `yaml:"preset,omitempty" json:"preset,omitempty"`
+	// Ignore lists predeclared identifiers to not report on, in the form
+	// of Config.Ignore.
+	Ignore []string `yaml:"ignore,omitempty" json:"ignore,omitempty"`
+	// Only lists the only predeclared identifiers to report on.
+	Only []string `yaml:"only,omitempty" json:"only,omitempty"`
+	// Qualified includes method names and field names in checks.
+	Qualified *bool `yaml:"qualified,omitempty" json:"qualified,omitempty"`
+	// Kinds enables or disables the reporting of declarations of each kind,
+	// e.g. "named-return": false.
+	Kinds map[string]bool `yaml:"kinds,omitempty" json:"kinds,omitempty"`
+	// Exclude lists patterns of files not to check, relative to the directory
+	// of the configuration file.
+	Exclude []string `yaml:"exclude,omitempty" json:"exclude,omitempty"`
+	// Params sets the policy for parameters and named results.
+	Params *paramsFile `yaml:"params,omitempty" json:"params,omitempty"`
+	// Severity is the severity of every report: info, warning or error.
+	Severity string `yaml:"severity,omitempty" json:"severity,omitempty"`
+	// Severities overrides the default severities of classes of shadowed
+	// identifiers, e.g. "builtin-function": "error".
+	Severities map[string]string `yaml:"severities,omitempty" json:"severities,omitempty"`
+	// Tests, ExternalTests and Examples amend the settings for test code, as
+	// the fields of Config of the same names do.
+	Tests         *policyFile `yaml:"tests,omitempty" json:"tests,omitempty"`
+	ExternalTests *policyFile `yaml:"external-tests,omitempty" json:"external-tests,omitempty"`
+	Examples      *policyFile `yaml:"examples,omitempty" json:"examples,omitempty"`
+}
+
+// A paramsFile is the part of a configuration file that sets the policy for
+// parameters and named results, as the Config fields SkipBodiless,
+// ExportedParams and ReferencedParams do.
+type paramsFile struct {
+	SkipBodiless *bool  `yaml:"skip-bodiless,omitempty" json:"skip-bodiless,omitempty"`
+	Exported     string `yaml:"exported,omitempty" json:"exported,omitempty"`
+	Referenced   *bool  `yaml:"referenced,omitempty" json:"referenced,omitempty"`
+}
+
+// A policyFile is the part of a configuration file that amends the settings
+// for test code.
+type policyFile struct {
+	// Ignore lists further predeclared identifiers to not report on.
+	Ignore []string `yaml:"ignore,omitempty" json:"ignore,omitempty"`
+	// Kinds enables or disables the reporting of declarations of each kind.
+	Kinds map[string]bool `yaml:"kinds,omitempty" json:"kinds,omitempty"`
+	// Severity is the severity of reports.
+	Severity string `yaml:"severity,omitempty" json:"severity,omitempty"`
+}
+
+// A preset is a named set of settings for a configuration file to start from.
+type preset struct {
+	qualified bool
+	kinds     map[Kind]bool
+}
+
+var presets = map[string]preset{
+	// strict reports declarations of every kind.
+	"strict": {qualified: true},
+	// default has the settings used when there is no configuration file.
+	"default": {},
+	// lenient reports only declarations that are visible outside of a
+	// function.
+	"lenient": {kinds: map[Kind]bool{
+		TypeParam:            false,
+		Receiver:             false,
+		Param:                false,
+		MethodParam:          false,
+		ClosureParam:         false,
+		InterfaceMethodParam: false,
+		FuncTypeParam:        false,
+		ExportedParam:        false,
+		NamedReturn:          false,
+		Label:                false,
+		LocalConst:           false,
+		LocalVar:             false,
+		RangeVar:             false,
+		TypeSwitchVar:        false,
+		SelectVar:            false,
+	}},
+}
+
+// fileSettings are the settings of the configuration files that apply to a
+// directory, merged.
+type fileSettings struct {
+	files  []string // configuration files, farthest first
+	ignore []string
+	// ignoreFrom is the configuration file that ignore is taken from.
+	ignoreFrom string
+	only       []string
+	qualified  bool
+	kinds      map[Kind]bool
+	excludes   []excludePattern
+	severity   Severity
+	severities map[Class]Severity
+	// skipBodiless, exportedParams and referencedParams are the policy for
+	// parameters and named results.
+	skipBodiless     bool
+	exportedParams   string
+	referencedParams bool
+	// tests, xtests and examples amend the settings for test code.
+	tests, xtests, examples policySettings
+}
+
+// An excludePattern is a pattern of files not to check, given in the
+// configuration file in dir.
+type excludePattern struct {
+	dir     string
+	pattern string
+}
+
+// loadFileSettings finds the configuration files that apply to dir, by
+// walking up from dir until the root of the file system or a file that sets
+// root, and merges them. Settings in nearer files override those in farther
+// ones, and exclude patterns accumulate. It returns nil if there are no
+// configuration files.
+func loadFileSettings(dir string) (*fileSettings, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	type found struct {
+		path string
+		cf   *configFile
+	}
+	var files []found
+	for {
+		p, cf, err := readConfigFile(dir)
+		if err != nil {
+			return nil, err
+		}
+		if cf != nil {
+			files = append(files, found{p, cf})
+			if cf.Root {
+				break
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	s := new(fileSettings)
+	for i := len(files) - 1; i >= 0; i-- {
+		if err := s.apply(files[i].path, files[i].cf); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// readConfigFile reads the configuration file in dir. It returns a nil
+// configFile if there is none.
+func readConfigFile(dir string) (string, *configFile, error) {
+	var p string
+	var data []byte
+	for _, name := range configFileNames {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		if p != "" {
+			return "", nil, fmt.Errorf("%s: more than one configuration file: %s and %s", dir, filepath.Base(p), name)
+		}
+		p, data = filepath.Join(dir, name), b
+	}
+	if p == "" {
+		return "", nil, nil
+	}
+	cf := new(configFile)
+	var err error
+	if filepath.Ext(p) == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(cf)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(cf); err == io.EOF {
+			err = nil // an empty file
+		}
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %v", p, err)
+	}
+	return p, cf, nil
+}
+
+// apply amends s with the settings of cf, read from the file at p.
+func (s *fileSettings) apply(p string, cf *configFile) error {
+	s.files = append(s.files, p)
+	if cf.Preset != "" {
+		pr, ok := presets[cf.Preset]
+		if !ok {
+			return fmt.Errorf("%s: unknown preset %q (want strict, default or lenient)", p, cf.Preset)
+		}
+		s.qualified = pr.qualified
+		s.kinds = make(map[Kind]bool, len(pr.kinds))
+		for k, v := range pr.kinds {
+			s.kinds[k] = v
+		}
+	}
+	if cf.Ignore != nil {
+		s.ignore, s.ignoreFrom = cf.Ignore, p
+	}
+	if cf.Only != nil {
+		s.only = cf.Only
+	}
+	if cf.Qualified != nil {
+		s.qualified = *cf.Qualified
+	}
+	if len(cf.Kinds) > 0 {
+		if s.kinds == nil {
+			s.kinds = make(map[Kind]bool)
+		}
+		if err := setKinds(s.kinds, cf.Kinds); err != nil {
+			return fmt.Errorf("%s: %v", p, err)
+		}
+	}
+	if pf := cf.Params; pf != nil {
+		if pf.SkipBodiless != nil {
+			s.skipBodiless = *pf.SkipBodiless
+		}
+		if pf.Exported != "" {
+			s.exportedParams = pf.Exported
+		}
+		if pf.Referenced != nil {
+			s.referencedParams = *pf.Referenced
+		}
+	}
+	if cf.Severity != "" {
+		sev, err := ParseSeverity(cf.Severity)
+		if err != nil {
+			return fmt.Errorf("%s: %v", p, err)
+		}
+		s.severity = sev
+	}
+	for name, level := range cf.Severities {
+		class, err := parseClass(name)
+		if err != nil {
+			return fmt.Errorf("%s: %v", p, err)
+		}
+		sev, err := ParseSeverity(level)
+		if err != nil {
+			return fmt.Errorf("%s: %v", p, err)
+		}
+		if s.severities == nil {
+			s.severities = make(map[Class]Severity)
+		}
+		s.severities[class] = sev
+	}
+	for _, x := range []struct {
+		s  *policySettings
+		pf *policyFile
+	}{
+		{&s.tests, cf.Tests},
+		{&s.xtests, cf.ExternalTests},
+		{&s.examples, cf.Examples},
+	} {
+		if x.pf == nil {
+			continue
+		}
+		if err := x.s.apply(x.pf); err != nil {
+			return fmt.Errorf("%s: %v", p, err)
+		}
+	}
+	for _, pattern := range cf.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%s: invalid exclude pattern %q", p, pattern)
+		}
+		s.excludes = append(s.excludes, excludePattern{filepath.Dir(p), pattern})
+	}
+	return nil
+}
+
+// apply amends s with the settings of pf: its ignore list, if any, replaces
+// that of s, and its kinds and severity override those of s.
+func (s *policySettings) apply(pf *policyFile) error {
+	if pf.Ignore != nil {
+		s.ignore = pf.Ignore
+	}
+	if len(pf.Kinds) > 0 {
+		kinds := make(map[Kind]bool)
+		for k, enabled := range s.kinds {
+			kinds[k] = enabled
+		}
+		if err := setKinds(kinds, pf.Kinds); err != nil {
+			return err
+		}
+		s.kinds = kinds
+	}
+	if pf.Severity != "" {
+		sev, err := ParseSeverity(pf.Severity)
+		if err != nil {
+			return err
+		}
+		s.severity = sev
+	}
+	return nil
+}
+
+// matches reports whether the file with the given name matches e. A pattern
+// matches a file if it matches the file's path relative to e.dir, or one of
+// the directories in that path. A pattern without a slash also matches a file
+// by its base name.
+func (e excludePattern) matches(filename string) bool {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(e.dir, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.Contains(e.pattern, "/") {
+		if ok, _ := path.Match(e.pattern, path.Base(rel)); ok {
+			return true
+		}
+	}
+	for p := rel; p != "."; p = path.Dir(p) {
+		if ok, _ := path.Match(e.pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// writeEffectiveConfig writes cfg, in the form of a configuration file, to w.
+// The comments at the top name the package and the configuration files that
+// apply to it.
+func writeEffectiveConfig(w io.Writer, pkgPath string, cfg *config) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# effective configuration for %s\n", pkgPath)
+	for _, f := range cfg.configFiles {
+		fmt.Fprintf(&buf, "# from %s\n", f)
+	}
+	cf := configFile{
+		Ignore:    cfg.ignoredIdents.entries(),
+		Only:      cfg.onlyIdents.entries(),
+		Qualified: &cfg.qualified,
+		Kinds:     make(map[string]bool),
+	}
+	for k := Kind(1); int(k) < len(kindNames); k++ {
+		cf.Kinds[k.key()] = cfg.enabled(k)
+	}
+	for _, e := range cfg.excludes {
+		cf.Exclude = append(cf.Exclude, filepath.ToSlash(filepath.Join(e.dir, e.pattern)))
+	}
+	if cfg.skipBodiless || cfg.exportedParams != "report" || cfg.referencedParams {
+		cf.Params = &paramsFile{Exported: cfg.exportedParams}
+		if cfg.skipBodiless {
+			cf.Params.SkipBodiless = &cfg.skipBodiless
+		}
+		if cfg.referencedParams {
+			cf.Params.Referenced = &cfg.referencedParams
+		}
+	}
+	if cfg.severity != 0 {
+		cf.Severity = cfg.severity.String()
+	}
+	cf.Severities = make(map[string]string)
+	for class, sev := range cfg.severities {
+		cf.Severities[string(class)] = sev.String()
+	}
+	cf.Tests = policyDiff(&cfg.policy, cfg.codePolicies[testCode])
+	cf.ExternalTests = policyDiff(cfg.codePolicies[testCode], cfg.codePolicies[externalTestCode])
+	cf.Examples = policyDiff(cfg.codePolicies[testCode], cfg.examplePolicies[testCode])
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(cf); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// policyDiff returns the settings by which p differs from parent, the policy
+// that it's derived from, or nil if it doesn't differ.
+func policyDiff(parent, p *policy) *policyFile {
+	pf := new(policyFile)
+	for _, entry := range p.ignoredIdents.entries() {
+		if _, ok := parent.ignoredIdents[entry]; !ok {
+			pf.Ignore = append(pf.Ignore, entry)
+		}
+	}
+	for k := Kind(1); int(k) < len(kindNames); k++ {
+		if p.enabled(k) != parent.enabled(k) {
+			if pf.Kinds == nil {
+				pf.Kinds = make(map[string]bool)
+			}
+			pf.Kinds[k.key()] = p.enabled(k)
+		}
+	}
+	if p.severity != parent.severity {
+		pf.Severity = p.severity.String()
+	}
+	if pf.Ignore == nil && pf.Kinds == nil && pf.Severity == "" {
+		return nil
+	}
+	return pf
+}
@@ -0,0 +1,250 @@
+package predeclared
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Suppression directives. Each may be followed by a list of entries, in the
+// form of -ignore entries, separated by spaces or commas, and then by
+// "-- reason". Without entries, a directive suppresses reports of every
+// identifier.
+const (
+	// lineDirective suppresses reports of the declarations on the line that
+	// it ends, or, if it's on a line of its own, on the next line.
+	lineDirective = "//predeclared:ignore"
+	// fileDirective suppresses reports in the file that holds it.
+	fileDirective = "//predeclared:file-ignore"
+	// packageDirective suppresses reports in every file of the package.
+	packageDirective = "//predeclared:package-ignore"
+)
+
+type directiveScope int
+
+const (
+	lineScope directiveScope = iota
+	fileScope
+	packageScope
+	// clauseScope is the scope of a line directive at a package clause. As a
+	// fix renames every package clause of a package, the directive covers
+	// the package clauses of every file.
+	clauseScope
+)
+
+// A directive is a comment that suppresses reports.
+type directive struct {
+	comment  *ast.Comment
+	group    *ast.CommentGroup
+	scope    directiveScope
+	line     int      // for line directives, the line that it applies to
+	idents   identSet // if empty, every identifier
+	reason   string
+	nolint   bool // whether the directive is a //nolint comment
+	trailing bool // whether the directive follows code on its line
+	used     bool // whether the directive has suppressed a report
+}
+
+// matches reports whether d suppresses the report of the declaration of name
+// of the given kind at line.
+func (d *directive) matches(kind Kind, name string, line int) bool {
+	switch d.scope {
+	case lineScope:
+		if line != d.line {
+			return false
+		}
+	case clauseScope:
+		if kind != PackageName {
+			return false
+		}
+	}
+	return len(d.idents) == 0 || d.idents.contains(kind, name)
+}
+
+// parseDirective parses the directive in c. It returns nil if c isn't a
+// directive. A //nolint comment that names the predeclared linter, or all
+// linters, is a line directive.
+func parseDirective(c *ast.Comment) (*directive, error) {
+	text := c.Text
+	d := &directive{comment: c, idents: identSet{}}
+	switch {
+	case hasDirectivePrefix(text, lineDirective):
+		text, d.scope = text[len(lineDirective):], lineScope
+	case hasDirectivePrefix(text, fileDirective):
+		text, d.scope = text[len(fileDirective):], fileScope
+	case hasDirectivePrefix(text, packageDirective):
+		text, d.scope = text[len(packageDirective):], packageScope
+	case isNolint(text):
+		d.nolint = true
+		if _, reason, ok := strings.Cut(text[2:], "//"); ok {
+			d.reason = strings.TrimSpace(reason)
+		}
+		return d, nil
+	default:
+		return nil, nil
+	}
+	text, reason, _ := strings.Cut(text, "--")
+	d.reason = strings.TrimSpace(reason)
+	for _, entry := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		if err := d.idents.add(entry); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func hasDirectivePrefix(text, prefix string) bool {
+	if !strings.HasPrefix(text, prefix) {
+		return false
+	}
+	rest := text[len(prefix):]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t'
+}
+
+// isNolint reports whether text is a //nolint comment that applies to the
+// predeclared linter, such as //nolint, //nolint:predeclared, or
+// //nolint:errcheck,predeclared // reason.
+func isNolint(text string) bool {
+	text = strings.TrimPrefix(text, "//")
+	text, _, _ = strings.Cut(text, "//")
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "nolint") {
+		return false
+	}
+	text = text[len("nolint"):]
+	if text == "" {
+		return true
+	}
+	if text[0] != ':' {
+		return false
+	}
+	for _, linter := range strings.Split(text[1:], ",") {
+		if linter = strings.TrimSpace(linter); linter == "predeclared" || linter == "all" {
+			return true
+		}
+	}
+	return false
+}
+
+// A directiveSet holds the directives in the files of a package.
+type directiveSet struct {
+	files map[*ast.File][]*directive // directives that apply to their file only
+	pkg   []*directive               // directives that apply to every file
+}
+
+// newDirectiveSet returns the directives in files. Invalid directives are
+// reported with report.
+func newDirectiveSet(fset *token.FileSet, files []*ast.File, report func(analysis.Diagnostic)) *directiveSet {
+	s := &directiveSet{files: make(map[*ast.File][]*directive)}
+	for _, file := range files {
+		for _, d := range directives(fset, file, report) {
+			if d.scope == packageScope || d.scope == clauseScope {
+				s.pkg = append(s.pkg, d)
+			} else {
+				s.files[file] = append(s.files[file], d)
+			}
+		}
+	}
+	return s
+}
+
+// forFile returns the directives that apply to file.
+func (s *directiveSet) forFile(file *ast.File) []*directive {
+	return append(append([]*directive(nil), s.files[file]...), s.pkg...)
+}
+
+// directives returns the directives in file. Line directives at the package
+// clause apply to every file, as package directives do; the others apply to
+// the file only. Invalid directives are reported with report.
+func directives(fset *token.FileSet, file *ast.File, report func(analysis.Diagnostic)) []*directive {
+	tf := fset.File(file.Package)
+	if tf == nil {
+		return nil
+	}
+	// codeStart holds, for each line with code on it, the position of the
+	// first code on the line. A comment after code on its line is a trailing
+	// comment.
+	codeStart := make(map[int]token.Pos)
+	note := func(pos token.Pos) {
+		if !pos.IsValid() {
+			return
+		}
+		line := tf.Line(pos)
+		if p, ok := codeStart[line]; !ok || pos < p {
+			codeStart[line] = pos
+		}
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.Comment, *ast.CommentGroup:
+			return false
+		}
+		note(n.Pos())
+		note(n.End() - 1)
+		return true
+	})
+
+	clauseLine := tf.Line(file.Package)
+	var dirs []*directive
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			d, err := parseDirective(c)
+			if err != nil {
+				report(analysis.Diagnostic{
+					Pos:     c.Pos(),
+					End:     c.End(),
+					Message: fmt.Sprintf("invalid directive: %s", err),
+				})
+				continue
+			}
+			if d == nil {
+				continue
+			}
+			d.group = group
+			line := tf.Line(c.Pos())
+			if p, ok := codeStart[line]; ok && p < c.Pos() {
+				d.trailing = true
+			}
+			if d.scope == lineScope {
+				if d.trailing {
+					d.line = line
+				} else {
+					d.line = tf.Line(group.End()) + 1
+				}
+				if d.line == clauseLine {
+					d.scope = clauseScope
+				}
+			}
+			dirs = append(dirs, d)
+		}
+	}
+	return dirs
+}
+
+// suppressed reports whether one of dirs suppresses the report of the
+// declaration of name of the given kind at line, and marks the directives
+// that do as used.
+func suppressed(dirs []*directive, kind Kind, name string, line int) bool {
+	var ok bool
+	for _, d := range dirs {
+		if d.matches(kind, name, line) {
+			d.used = true
+			ok = true
+		}
+	}
+	return ok
+}
+
+// clauseSuppressed reports whether a directive in s suppresses the reports of
+// the package clause name, without marking it as used.
+func clauseSuppressed(s *directiveSet, name string) bool {
+	for _, d := range s.pkg {
+		if d.matches(PackageName, name, 0) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package predeclared
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// A pathPattern is an -exclude pattern: a glob, or a regular expression
+// written with the prefix "re:". Patterns are matched against slash-separated
+// file paths relative to the working directory, or absolute paths for files
+// outside of it.
+type pathPattern struct {
+	text string
+	re   *regexp.Regexp
+}
+
+func parsePathPattern(s string) (pathPattern, error) {
+	if expr, ok := strings.CutPrefix(s, "re:"); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return pathPattern{}, fmt.Errorf("invalid -%s pattern %q: %v", ExcludeFlag, s, err)
+		}
+		return pathPattern{s, re}, nil
+	}
+	re, err := globRegexp(s)
+	if err != nil {
+		return pathPattern{}, fmt.Errorf("invalid -%s pattern %q: %v", ExcludeFlag, s, err)
+	}
+	return pathPattern{s, re}, nil
+}
+
+func (p pathPattern) matches(filename string) bool {
+	return p.re.MatchString(matchPath(filename))
+}
+
+// matchPath returns the form of filename that pathPatterns are matched
+// against.
+func matchPath(filename string) string {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return filepath.ToSlash(filename)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(abs)
+}
+
+// globRegexp returns a regular expression that matches the same paths as the
+// glob pattern. In addition to the syntax of path.Match, "**" matches any
+// number of path elements: "**/testdata/**" matches every file in a testdata
+// directory at any depth.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**") {
+				i++
+				if strings.HasPrefix(pattern[i+1:], "/") {
+					i++
+					b.WriteString("(.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			j := strings.IndexByte(pattern[i:], ']')
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+			b.WriteString(pattern[i : i+j+1])
+			i += j
+		case '\\':
+			if i+1 == len(pattern) {
+				return nil, fmt.Errorf("trailing backslash")
+			}
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// isVendored reports whether the package with the given import path is
+// vendored. The path, not the directory of the package, is tested, as only a
+// "vendor" directory inside the module or GOPATH tree makes a package
+// vendored.
+func isVendored(path string) bool {
+	for _, elem := range strings.Split(path, "/") {
+		if elem == "vendor" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,489 @@
+package predeclared
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// defaultRenames lists idiomatic replacement names for each predeclared
+// identifier, in order of preference.
+var defaultRenames = map[string][]string{
+	"bool":       {"b", "ok"},
+	"byte":       {"b", "c"},
+	"complex64":  {"c", "z"},
+	"complex128": {"c", "z"},
+	"error":      {"err"},
+	"float32":    {"f", "x"},
+	"float64":    {"f", "x"},
+	"int":        {"i", "n"},
+	"int8":       {"i", "n"},
+	"int16":      {"i", "n"},
+	"int32":      {"i", "n"},
+	"int64":      {"i", "n"},
+	"rune":       {"r", "c"},
+	"string":     {"s", "str"},
+	"uint":       {"u", "n"},
+	"uint8":      {"u", "b"},
+	"uint16":     {"u", "n"},
+	"uint32":     {"u", "n"},
+	"uint64":     {"u", "n"},
+	"uintptr":    {"ptr", "p"},
+	"any":        {"v", "val"},
+	"comparable": {"c", "cmp"},
+
+	"true":  {"yes", "t"},
+	"false": {"no", "f"},
+	"iota":  {"i", "idx"},
+
+	"nil": {"none", "null"},
+
+	"append":  {"appendX"},
+	"cap":     {"c", "capacity"},
+	"close":   {"closeX"},
+	"complex": {"c", "z"},
+	"copy":    {"cp"},
+	"delete":  {"del"},
+	"imag":    {"im"},
+	"len":     {"n", "length"},
+	"make":    {"mk"},
+	"new":     {"newX"},
+	"panic":   {"panicX"},
+	"print":   {"printX"},
+	"println": {"printlnX"},
+	"real":    {"re"},
+	"recover": {"recoverX"},
+	"clear":   {"clearX"},
+	"max":     {"hi", "maximum"},
+	"min":     {"lo", "minimum"},
+}
+
+// typeParamRenames lists the preferred replacement names for type parameters.
+var typeParamRenames = []string{"T", "U", "V"}
+
+// renamedKinds lists the kinds of declaration that fixes rename.
+var renamedKinds = []Kind{
+	Const,
+	Var,
+	Type,
+	Func,
+	Receiver,
+	Param,
+	NamedReturn,
+	RangeVar,
+	TypeSwitchVar,
+	TypeParam,
+	LocalConst,
+	LocalVar,
+	MethodParam,
+	ClosureParam,
+	InterfaceMethodParam,
+	FuncTypeParam,
+	SelectVar,
+	ExportedParam,
+}
+
+// A renameMap maps a predeclared identifier to the name that declarations of
+// that name should be renamed to. Keys are either the identifier alone, or
+// the identifier qualified by a declaration kind, as in "param:new". Kinds
+// containing spaces are written with hyphens, as in "named-return:len".
+type renameMap map[string]string
+
+// lookup returns the replacement for the declaration of name of the given
+// kind. A replacement qualified by kind takes precedence, then one qualified
+// by an alias that covers kind.
+func (m renameMap) lookup(kind Kind, name string) (string, bool) {
+	if r, ok := m[kind.key()+":"+name]; ok {
+		return r, true
+	}
+	if alias := kind.alias(); alias != "" {
+		if r, ok := m[alias+":"+name]; ok {
+			return r, true
+		}
+	}
+	r, ok := m[name]
+	return r, ok
+}
+
+// parseFile parses the replacements in the named file into m. The file holds
+// one [kind:]ident=name replacement per line. Blank lines and lines starting
+// with '#' are ignored.
+func (m renameMap) parseFile(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for lineno := 1; sc.Scan(); lineno++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := m.parseEntry(line); err != nil {
+			return fmt.Errorf("%s:%d: %s", filename, lineno, err)
+		}
+	}
+	return sc.Err()
+}
+
+func (m renameMap) parseEntry(entry string) error {
+	key, name, ok := strings.Cut(entry, "=")
+	if !ok {
+		return fmt.Errorf("invalid rename %q: want [kind:]ident=name", entry)
+	}
+	key, name = strings.TrimSpace(key), strings.TrimSpace(name)
+	ident := key
+	if s, rest, ok := strings.Cut(key, ":"); ok {
+		kindKey, kinds, err := parseKindKey(s)
+		if err != nil {
+			return fmt.Errorf("invalid rename %q: %s", entry, err)
+		}
+		if !isRenamedKind(kinds[0]) {
+			return fmt.Errorf("invalid rename %q: declarations of kind %q aren't renamed", entry, s)
+		}
+		ident = rest
+		key = kindKey + ":" + ident
+	}
+	if !isPredeclared(ident, "") {
+		return fmt.Errorf("invalid rename %q: %s is not a predeclared identifier%s", entry, ident, suggest(ident, predeclaredNames()))
+	}
+	if !token.IsIdentifier(name) || isPredeclared(name, "") {
+		return fmt.Errorf("invalid rename %q: %q is not a valid replacement name", entry, name)
+	}
+	m[key] = name
+	return nil
+}
+
+func isRenamedKind(kind Kind) bool {
+	for _, k := range renamedKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// renameFix returns a suggested fix that renames the declaration of obj at
+// ident, together with every use of obj. implicits lists further objects that
+// are declared by ident, as happens with a type switch guard, whose uses are
+// renamed too. The preferred name, if any, is tried before the defaults.
+// renameFix returns nil if obj can't be renamed safely.
+func renameFix(pkg *pkgInfo, ident *ast.Ident, obj types.Object, implicits []types.Object, preferred string) *analysis.SuggestedFix {
+	if !isRenameable(obj) {
+		return nil
+	}
+	objs := make(map[types.Object]bool)
+	objs[obj] = true
+	for _, o := range implicits {
+		objs[o] = true
+	}
+
+	idents := []*ast.Ident{ident}
+	for o := range objs {
+		for _, id := range pkg.uses(o) {
+			if id == ident {
+				continue
+			}
+			if _, isEmbedded := pkg.info.Defs[id].(*types.Var); isEmbedded {
+				// The type is also the name of an embedded field, and
+				// renaming it would rename the field too.
+				return nil
+			}
+			idents = append(idents, id)
+		}
+	}
+
+	newName := chooseName(pkg, obj, objs, idents, renameCandidates(obj, preferred))
+	if newName == "" {
+		return nil
+	}
+
+	return &analysis.SuggestedFix{
+		Message:   fmt.Sprintf("Rename %s to %s", obj.Name(), newName),
+		TextEdits: renameEdits(idents, newName),
+	}
+}
+
+// isRenameable reports whether renameFix knows how to rename obj. Fields,
+// methods, and labels don't shadow anything, and package names are handled
+// separately.
+func isRenameable(obj types.Object) bool {
+	if obj == nil || obj.Pkg() == nil || obj.Parent() == nil {
+		return false
+	}
+	switch obj := obj.(type) {
+	case *types.Var:
+		return !obj.IsField()
+	case *types.Const, *types.TypeName:
+		return true
+	case *types.Func:
+		return obj.Type().(*types.Signature).Recv() == nil
+	}
+	return false
+}
+
+// renameCandidates returns the names, in order of preference, that obj may be
+// renamed to, starting with preferred, if non-empty.
+func renameCandidates(obj types.Object, preferred string) []string {
+	var candidates []string
+	if preferred != "" {
+		candidates = append(candidates, preferred)
+	}
+	if tn, ok := obj.(*types.TypeName); ok {
+		if _, ok := tn.Type().(*types.TypeParam); ok {
+			candidates = append(candidates, typeParamRenames...)
+		}
+	}
+	candidates = append(candidates, defaultRenames[obj.Name()]...)
+	if len(candidates) == 0 {
+		candidates = append(candidates, obj.Name()+"X")
+	}
+	return candidates
+}
+
+// chooseName returns the first of candidates that objs, whose declaration and
+// uses are at idents, can be renamed to without a conflict. If every
+// candidate conflicts, numbered variants of the first candidate are tried.
+func chooseName(pkg *pkgInfo, obj types.Object, objs map[types.Object]bool, idents []*ast.Ident, candidates []string) string {
+	for _, name := range candidates {
+		if canRename(pkg, obj, objs, idents, name) {
+			return name
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	for i := 2; i < 100; i++ {
+		name := candidates[0] + strconv.Itoa(i)
+		if canRename(pkg, obj, objs, idents, name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// canRename reports whether objs can be renamed to newName. That's so if
+// newName doesn't collide with another object visible in any scope that the
+// rename touches, and if no existing reference to another object named
+// newName would be captured by the renamed declaration.
+func canRename(pkg *pkgInfo, obj types.Object, objs map[types.Object]bool, idents []*ast.Ident, newName string) bool {
+	if token.IsKeyword(newName) || isPredeclared(newName, "") {
+		return false
+	}
+	pkgScope := obj.Pkg().Scope()
+
+	// Conflicts with declarations in the same block, whether they come
+	// before or after this one.
+	for o := range objs {
+		if o.Parent().Lookup(newName) != nil {
+			return false
+		}
+	}
+	switch pkgScope {
+	case obj.Parent():
+		// Package-level names also conflict with imports in every file.
+		for i := 0; i < pkgScope.NumChildren(); i++ {
+			if pkgScope.Child(i).Lookup(newName) != nil {
+				return false
+			}
+		}
+	case obj.Parent().Parent():
+		// Imports conflict with package-level names.
+		if pkgScope.Lookup(newName) != nil {
+			return false
+		}
+	}
+
+	// Conflicts with objects that are visible at the declaration or at a use.
+	for _, id := range idents {
+		scope := pkgScope.Innermost(id.Pos())
+		if scope == nil {
+			return false
+		}
+		if _, o := scope.LookupParent(newName, id.Pos()); o != nil && !objs[o] {
+			return false
+		}
+	}
+
+	// References to an outer object named newName would be captured if they
+	// are within the renamed object's scope.
+	pkg.countRefs()
+	for _, id := range pkg.namedRefs[newName] {
+		o := pkg.info.Uses[id]
+		if objs[o] || o.Parent() == nil {
+			continue
+		}
+		for renamed := range objs {
+			declScope := renamed.Parent()
+			if declScope != pkgScope && !declScope.Contains(id.Pos()) {
+				continue
+			}
+			if isAncestorScope(o.Parent(), declScope) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// aliasFix returns a suggested fix that replaces the alias of the import
+// spec, which declares pkgName, with a name derived from the import path, and
+// renames the references to the alias to match.
+func aliasFix(pkg *pkgInfo, spec *ast.ImportSpec, pkgName *types.PkgName) *analysis.SuggestedFix {
+	if pkgName == nil {
+		return nil
+	}
+	objs := map[types.Object]bool{pkgName: true}
+	idents := append([]*ast.Ident{spec.Name}, pkg.uses(pkgName)...)
+	newName := chooseName(pkg, pkgName, objs, idents, importNameCandidates(pkgName.Imported().Path(), pkgName.Imported().Name()))
+	if newName == "" {
+		return nil
+	}
+	return &analysis.SuggestedFix{
+		Message:   fmt.Sprintf("Rename import %s to %s", pkgName.Name(), newName),
+		TextEdits: renameEdits(idents, newName),
+	}
+}
+
+// importerFix returns a suggested fix for a file that imports the package of
+// pkgName without an alias, while the package is renamed to newName. The
+// fix rewrites the file's references to the package. If newName isn't free in
+// the file, the fix also gives the import an alias that is.
+func importerFix(pkg *pkgInfo, spec *ast.ImportSpec, pkgName *types.PkgName, newName string) *analysis.SuggestedFix {
+	objs := map[types.Object]bool{pkgName: true}
+	idents := pkg.uses(pkgName)
+	if canRename(pkg, pkgName, objs, idents, newName) {
+		return &analysis.SuggestedFix{
+			Message:   fmt.Sprintf("Rename references to package %s to %s", pkgName.Name(), newName),
+			TextEdits: renameEdits(idents, newName),
+		}
+	}
+	alias := chooseName(pkg, pkgName, objs, idents, []string{newName})
+	if alias == "" {
+		return nil
+	}
+	return &analysis.SuggestedFix{
+		Message: fmt.Sprintf("Import package %s as %s", pkgName.Name(), alias),
+		TextEdits: append(renameEdits(idents, alias), analysis.TextEdit{
+			Pos:     spec.Path.Pos(),
+			End:     spec.Path.Pos(),
+			NewText: []byte(alias + " "),
+		}),
+	}
+}
+
+// packageRename returns the name that the package with the given import path,
+// whose package clause name is the predeclared identifier name, is renamed to.
+// It depends only on its arguments, so that the package and its importers
+// agree on it.
+func packageRename(path, name string) string {
+	return importNameCandidates(path, name)[0]
+}
+
+// importNameCandidates returns the names, in order of preference, to give a
+// package with the given import path and package clause name. The names are
+// derived from the import path, so that they read well at the package's call
+// sites.
+func importNameCandidates(path, name string) []string {
+	elems := strings.Split(path, "/")
+	// Skip a major version suffix, as in example.org/mod/v2.
+	if n := len(elems); n > 1 && isMajorVersion(elems[n-1]) {
+		elems = elems[:n-1]
+	}
+	var candidates []string
+	add := func(s string) {
+		if token.IsIdentifier(s) && !token.IsKeyword(s) && !isPredeclared(s, "") {
+			candidates = append(candidates, s)
+		}
+	}
+	last := sanitizeName(elems[len(elems)-1])
+	add(last)
+	add(sanitizeName(name))
+	if len(elems) > 1 {
+		add(sanitizeName(elems[len(elems)-2]) + last)
+	}
+	add(sanitizeName(name) + "pkg")
+	return candidates
+}
+
+// sanitizeName returns s, a path element, with characters that can't appear
+// in an identifier removed.
+func sanitizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '_' {
+			return r
+		}
+		return -1
+	}, strings.ToLower(s))
+}
+
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(s[1:])
+	return err == nil
+}
+
+// uses returns the identifiers that refer to obj.
+func (p *pkgInfo) uses(obj types.Object) []*ast.Ident {
+	p.countRefs()
+	return p.refs[obj]
+}
+
+// renameEdits returns the edits that replace each of idents with newName.
+func renameEdits(idents []*ast.Ident, newName string) []analysis.TextEdit {
+	var edits []analysis.TextEdit
+	for _, id := range idents {
+		edits = append(edits, analysis.TextEdit{
+			Pos:     id.Pos(),
+			End:     id.End(),
+			NewText: []byte(newName),
+		})
+	}
+	return edits
+}
+
+// describeEdits summarizes the files that edits touch, e.g.
+// "a.go (2 edits), b.go (1 edit)".
+func describeEdits(fset *token.FileSet, edits []analysis.TextEdit) string {
+	var files []string
+	counts := make(map[string]int)
+	for _, e := range edits {
+		name := filepath.Base(fset.File(e.Pos).Name())
+		if counts[name] == 0 {
+			files = append(files, name)
+		}
+		counts[name]++
+	}
+	sort.Strings(files)
+	var parts []string
+	for _, name := range files {
+		unit := "edits"
+		if counts[name] == 1 {
+			unit = "edit"
+		}
+		parts = append(parts, fmt.Sprintf("%s (%d %s)", name, counts[name], unit))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// isAncestorScope reports whether outer is scope or one of its ancestors.
+func isAncestorScope(outer, scope *types.Scope) bool {
+	for s := scope; s != nil; s = s.Parent() {
+		if s == outer {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package predeclared
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is the kind of a declaration that has the same name as a predeclared
+// identifier.
+type Kind int
+
+// Kinds of declarations.
+const (
+	PackageName          Kind = iota + 1 // package clause name
+	ImportName                           // import alias
+	ImplicitImportName                   // name declared by an import without an alias
+	Const                                // package-level constant
+	Var                                  // package-level variable
+	Type                                 // type
+	TypeParam                            // type parameter
+	Func                                 // function
+	Method                               // method, including interface methods
+	Field                                // struct field
+	Receiver                             // method receiver
+	Param                                // parameter of a function declaration
+	NamedReturn                          // named result parameter
+	Label                                // label
+	RangeVar                             // variable declared by a range clause
+	TypeSwitchVar                        // variable declared by a type switch guard
+	LocalConst                           // constant declared in a function
+	LocalVar                             // variable declared in a function
+	MethodParam                          // parameter of a method declaration
+	ClosureParam                         // parameter of a function literal
+	InterfaceMethodParam                 // parameter of an interface method
+	FuncTypeParam                        // parameter of a function type
+	SelectVar                            // variable declared by a select case
+	EmbeddedField                        // embedded struct field
+	ExportedParam                        // parameter or named result of exported API, if Config.ExportedParams is "separate"
+)
+
+var kindNames = [...]string{
+	PackageName:          "package name",
+	ImportName:           "import name",
+	ImplicitImportName:   "implicit import name",
+	Const:                "const",
+	Var:                  "variable",
+	Type:                 "type",
+	TypeParam:            "type parameter",
+	Func:                 "function",
+	Method:               "method",
+	Field:                "field",
+	Receiver:             "receiver",
+	Param:                "param",
+	NamedReturn:          "named return",
+	Label:                "label",
+	RangeVar:             "range variable",
+	TypeSwitchVar:        "type switch variable",
+	LocalConst:           "local const",
+	LocalVar:             "local variable",
+	MethodParam:          "method param",
+	ClosureParam:         "closure param",
+	InterfaceMethodParam: "interface method param",
+	FuncTypeParam:        "func type param",
+	SelectVar:            "select case variable",
+	EmbeddedField:        "embedded field",
+	ExportedParam:        "exported param",
+}
+
+// String returns the name of k used in diagnostics, e.g. "named return".
+func (k Kind) String() string {
+	if k <= 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+	return kindNames[k]
+}
+
+// kindKeys holds the names used in configuration of the kinds whose String
+// form is also the name of an alias.
+var kindKeys = map[Kind]string{
+	Const: "package-const",
+	Var:   "package-variable",
+	Param: "function-param",
+}
+
+// key returns the name of k used in configuration, which is its String form
+// with hyphens for spaces, e.g. "named-return", unless that's the name of an
+// alias.
+func (k Kind) key() string {
+	if key, ok := kindKeys[k]; ok {
+		return key
+	}
+	return strings.ReplaceAll(k.String(), " ", "-")
+}
+
+// kindAliases maps the names that kinds had before they were split into
+// narrower ones to the kinds that they cover now. Settings may use them: for
+// example, "param" stands for the parameters of every kind of function, as
+// it once did.
+var kindAliases = map[string][]Kind{
+	"const":    {Const, LocalConst},
+	"variable": {Var, LocalVar, SelectVar},
+	"param":    {Param, MethodParam, ClosureParam, InterfaceMethodParam, FuncTypeParam},
+}
+
+// alias returns the name of the alias that covers k, or the empty string if
+// there is none.
+func (k Kind) alias() string {
+	for name, kinds := range kindAliases {
+		for _, kind := range kinds {
+			if kind == k {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// ParseKind returns the Kind with the given name. The name may be either the
+// String form of the kind, e.g. "named return", or the form used in
+// configuration, with hyphens for spaces, e.g. "named-return".
+//
+// ParseKind doesn't expand aliases: ParseKind("param") returns Param, the
+// parameters of function declarations alone, whereas "param" stands for the
+// parameters of every kind of function in settings such as -disable. Use
+// ParseKinds to parse names as settings do.
+func ParseKind(s string) (Kind, error) {
+	for k := Kind(1); int(k) < len(kindNames); k++ {
+		if s == k.String() || s == k.key() {
+			return k, nil
+		}
+	}
+	var keys []string
+	for k := Kind(1); int(k) < len(kindNames); k++ {
+		keys = append(keys, k.key())
+	}
+	for name := range kindAliases {
+		keys = append(keys, name)
+	}
+	return 0, fmt.Errorf("unknown declaration kind %q%s", s, suggest(s, keys))
+}
+
+// ParseKinds returns the kinds that the name s stands for in settings, such
+// as -enable and -disable: the kind named s, as for ParseKind, or the kinds
+// that the alias named s covers. For example, "param" stands for Param,
+// MethodParam, ClosureParam, InterfaceMethodParam and FuncTypeParam, and
+// "variable" for Var, LocalVar and SelectVar.
+func ParseKinds(s string) ([]Kind, error) {
+	_, kinds, err := parseKindKey(s)
+	return append([]Kind(nil), kinds...), err
+}
+
+// parseKindKey returns the name used in configuration of the kind or alias
+// named s, and the kinds that it covers.
+func parseKindKey(s string) (string, []Kind, error) {
+	if kinds, ok := kindAliases[s]; ok {
+		return s, kinds, nil
+	}
+	k, err := ParseKind(s)
+	if err != nil {
+		return "", nil, err
+	}
+	return k.key(), []Kind{k}, nil
+}
+
+// setKinds sets, in kinds, whether each of the kinds or aliases named in
+// names is enabled. The kinds named on their own take precedence over the
+// aliases that cover them.
+func setKinds(kinds map[Kind]bool, names map[string]bool) error {
+	specific := make(map[Kind]bool)
+	for name, enabled := range names {
+		_, ks, err := parseKindKey(name)
+		if err != nil {
+			return err
+		}
+		for _, k := range ks {
+			if _, isAlias := kindAliases[name]; !isAlias {
+				kinds[k] = enabled
+				specific[k] = true
+			} else if !specific[k] {
+				kinds[k] = enabled
+			}
+		}
+	}
+	return nil
+}
+
+// qualifiedKinds are the kinds of declarations that are accessed through a
+// qualifier, as in x.Field, and are reported by default only in qualified
+// mode.
+var qualifiedKinds = map[Kind]bool{Field: true, Method: true, EmbeddedField: true}
@@ -0,0 +1,175 @@
+package predeclared
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A Policy amends the settings of a Config for a part of the code, such as
+// the test files of a package. The zero Policy amends nothing.
+type Policy struct {
+	// Ignore lists further predeclared identifiers to not report on, in the
+	// form of Config.Ignore entries.
+	Ignore []string
+	// Enable and Disable list kinds of declarations to report, or not,
+	// overriding the settings that apply otherwise. A kind may not be listed
+	// in both.
+	Enable, Disable []Kind
+	// Severity, if set, is the severity of reports.
+	Severity Severity
+}
+
+// registerFlags registers flags that set the fields of p in fs. The names of
+// the flags are those of the Config flags that they amend, with the given
+// prefix. code describes the code that p applies to.
+func (p *Policy) registerFlags(fs *flag.FlagSet, prefix, code string) {
+	fs.Var((*listFlag)(&p.Ignore), prefix+IgnoreFlag, "comma-separated list of further [kind:]ident or [kind:]@group entries to not report on in "+code)
+	fs.Var((*kindListFlag)(&p.Enable), prefix+EnableFlag, "comma-separated list of kinds of declarations to report in "+code)
+	fs.Var((*kindListFlag)(&p.Disable), prefix+DisableFlag, "comma-separated list of kinds of declarations to not report in "+code)
+	fs.Var((*severityFlag)(&p.Severity), prefix+SeverityFlag, "severity of reports in "+code+" (info, warning or error)")
+}
+
+// settings returns the policySettings of p. The flags of errors are named
+// with the given prefix.
+func (p Policy) settings(prefix string) (policySettings, error) {
+	s := policySettings{ignore: p.Ignore, severity: p.Severity}
+	if len(p.Enable) > 0 || len(p.Disable) > 0 {
+		s.kinds = make(map[Kind]bool)
+	}
+	for _, k := range p.Enable {
+		s.kinds[k] = true
+	}
+	for _, k := range p.Disable {
+		if containsKind(p.Enable, k) {
+			return policySettings{}, fmt.Errorf("-%s%s: kind %s is both enabled and disabled", prefix, DisableFlag, k.key())
+		}
+		s.kinds[k] = false
+	}
+	return s, nil
+}
+
+// A policy holds the settings of a config that may differ between parts of
+// the code.
+type policy struct {
+	qualified     bool
+	ignoredIdents identSet
+	onlyIdents    identSet // if empty, all identifiers are reported on
+	// kinds enables or disables the reporting of declarations by kind. Kinds
+	// not in the map are enabled, except the qualifiedKinds, which are
+	// enabled in qualified mode.
+	kinds map[Kind]bool
+	// severity, if set, is the severity of reports, instead of the severity
+	// of the class of the shadowed identifier.
+	severity Severity
+}
+
+// selected reports whether the declaration of name of the given kind is
+// reported on, as far as the identifier is concerned. The ignore entries that
+// exclude the declaration are recorded in used, if it's non-nil.
+func (p *policy) selected(kind Kind, name string, used map[string]bool) bool {
+	if entries := p.ignoredIdents.matching(kind, name); len(entries) > 0 {
+		for _, entry := range entries {
+			if used != nil {
+				used[entry] = true
+			}
+		}
+		return false
+	}
+	return len(p.onlyIdents) == 0 || p.onlyIdents.contains(kind, name)
+}
+
+// enabled reports whether declarations of kind k are reported.
+func (p *policy) enabled(k Kind) bool {
+	if enabled, ok := p.kinds[k]; ok {
+		return enabled
+	}
+	if qualifiedKinds[k] {
+		return p.qualified
+	}
+	return true
+}
+
+// policySettings are amendments to a policy, from a Policy or from the
+// configuration files that apply to a package.
+type policySettings struct {
+	ignore   []string
+	kinds    map[Kind]bool
+	severity Severity
+}
+
+// amend returns a copy of p amended by each of settings in turn: further
+// identifiers are ignored, and kinds and severity are overridden. Invalid
+// entries are reported as entries of the flag with the given prefix.
+func (p *policy) amend(prefix string, settings ...policySettings) (*policy, error) {
+	q := &policy{
+		qualified:     p.qualified,
+		ignoredIdents: identSet{},
+		onlyIdents:    p.onlyIdents,
+		kinds:         make(map[Kind]bool),
+		severity:      p.severity,
+	}
+	for entry := range p.ignoredIdents {
+		q.ignoredIdents[entry] = struct{}{}
+	}
+	for k, enabled := range p.kinds {
+		q.kinds[k] = enabled
+	}
+	for _, s := range settings {
+		for _, entry := range s.ignore {
+			if err := q.ignoredIdents.add(entry); err != nil {
+				return nil, fmt.Errorf("-%s%s: %s", prefix, IgnoreFlag, err)
+			}
+		}
+		for k, enabled := range s.kinds {
+			q.kinds[k] = enabled
+		}
+		if s.severity != 0 {
+			q.severity = s.severity
+		}
+	}
+	return q, nil
+}
+
+// A codeKind is a part of a package's code that has a policy of its own.
+type codeKind int
+
+const (
+	// productionCode is the code that isn't in test files.
+	productionCode codeKind = iota
+	// testCode is the code of the _test.go files that belong to the package
+	// itself.
+	testCode
+	// externalTestCode is the code of the _test.go files of the external
+	// test package, such as package fmt_test.
+	externalTestCode
+)
+
+// codeKindOf returns the kind of code in the file with the given name.
+func codeKindOf(filename string, file *ast.File) codeKind {
+	if !strings.HasSuffix(filename, "_test.go") {
+		return productionCode
+	}
+	if strings.HasSuffix(file.Name.Name, "_test") {
+		return externalTestCode
+	}
+	return testCode
+}
+
+// isExampleFunc reports whether decl, in a file of the given kind of code,
+// declares an Example, Benchmark or Fuzz function, as recognized by go test.
+func isExampleFunc(code codeKind, decl *ast.FuncDecl) bool {
+	if code == productionCode || decl.Recv != nil {
+		return false
+	}
+	for _, prefix := range []string{"Example", "Benchmark", "Fuzz"} {
+		if rest, ok := strings.CutPrefix(decl.Name.Name, prefix); ok {
+			r, _ := utf8.DecodeRuneInString(rest)
+			return rest == "" || !unicode.IsLower(r)
+		}
+	}
+	return false
+}
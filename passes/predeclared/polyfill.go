@@ -0,0 +1,313 @@
+package predeclared
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"go/token"
+	"go/types"
+	"go/version"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// A polyfillStatus classifies a package-level declaration of a predeclared
+// identifier that was added after Go 1.0.
+type polyfillStatus int
+
+const (
+	// notPolyfill is a declaration that merely shadows the identifier.
+	notPolyfill polyfillStatus = iota
+	// polyfill is a declaration that is compatible with the identifier,
+	// and that build constraints restrict to the Go versions that lack it,
+	// such as
+	//
+	//	//go:build !go1.21
+	//
+	//	func min[T cmp.Ordered](x, y T) T { ... }
+	polyfill
+	// obsoletePolyfill is a declaration that is compatible with the
+	// identifier, in a file whose Go version has it, so that the declaration
+	// shadows it.
+	obsoletePolyfill
+)
+
+// polyfillStatusOf classifies decl, a package-level function declaration or
+// type spec in file that declares name. fileVersion is the language version
+// of the file, or empty if unknown. info may be nil, in which case
+// compatibility is judged from syntax.
+func polyfillStatusOf(fileVersion string, file *ast.File, info *types.Info, name *ast.Ident, decl ast.Node) polyfillStatus {
+	p, ok := predeclaredByName[name.Name]
+	if !ok || p.since == "go1" || !compatibleDecl(info, name, decl) {
+		return notPolyfill
+	}
+	switch {
+	case excludesVersion(buildConstraint(file), p.since):
+		// The file is built only by toolchains older than p.since, whatever
+		// the version of the module, so the declaration shadows nothing.
+		return polyfill
+	case fileVersion != "" && version.Compare(fileVersion, p.since) >= 0:
+		return obsoletePolyfill
+	}
+	return notPolyfill
+}
+
+// compatibleDecl reports whether decl, which declares name, is compatible
+// with the predeclared identifier of the same name: code written for the
+// predeclared identifier would mean the same with decl, as far as can be
+// told. Without info, it's judged from the shape of decl alone.
+func compatibleDecl(info *types.Info, name *ast.Ident, decl ast.Node) bool {
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		if decl.Recv != nil {
+			return false
+		}
+		switch name.Name {
+		case "min", "max":
+			return compatibleMinMax(info, name, decl.Type)
+		case "clear":
+			return compatibleClear(info, name, decl.Type)
+		}
+	case *ast.TypeSpec:
+		if name.Name == "any" {
+			// type any = interface{}
+			iface, ok := decl.Type.(*ast.InterfaceType)
+			return decl.Assign.IsValid() && ok && iface.Methods.NumFields() == 0
+		}
+	}
+	return false
+}
+
+// compatibleMinMax reports whether the function type ft, of the function
+// declared by name, is compatible with the min and max builtins: it has at
+// least one parameter, and one result, all of the same type, which is
+// ordered.
+func compatibleMinMax(info *types.Info, name *ast.Ident, ft *ast.FuncType) bool {
+	if ft.Params.NumFields() == 0 || ft.Results.NumFields() != 1 {
+		return false
+	}
+	if info != nil {
+		if fn, ok := info.Defs[name].(*types.Func); ok {
+			sig := fn.Type().(*types.Signature)
+			result := sig.Results().At(0).Type()
+			for i := 0; i < sig.Params().Len(); i++ {
+				t := sig.Params().At(i).Type()
+				if sig.Variadic() && i == sig.Params().Len()-1 {
+					t = t.(*types.Slice).Elem()
+				}
+				if !types.Identical(t, result) {
+					return false
+				}
+			}
+			return typeSetAll(result, isOrdered)
+		}
+	}
+	result := types.ExprString(ft.Results.List[0].Type)
+	for _, field := range ft.Params.List {
+		t := field.Type
+		if ellipsis, ok := t.(*ast.Ellipsis); ok {
+			t = ellipsis.Elt
+		}
+		if types.ExprString(t) != result {
+			return false
+		}
+	}
+	return true
+}
+
+// compatibleClear reports whether the function type ft, of the function
+// declared by name, is compatible with the clear builtin: it has one
+// parameter, of a map or slice type, and no result.
+func compatibleClear(info *types.Info, name *ast.Ident, ft *ast.FuncType) bool {
+	if ft.Params.NumFields() != 1 || ft.Results.NumFields() != 0 {
+		return false
+	}
+	if info != nil {
+		if fn, ok := info.Defs[name].(*types.Func); ok {
+			sig := fn.Type().(*types.Signature)
+			return !sig.Variadic() && typeSetAll(sig.Params().At(0).Type(), isClearable)
+		}
+	}
+	return true
+}
+
+// isOrdered reports whether values of the underlying type u can be compared
+// with <, as the arguments of min and max must be.
+func isOrdered(u types.Type) bool {
+	b, ok := u.(*types.Basic)
+	return ok && b.Info()&types.IsOrdered != 0
+}
+
+// isClearable reports whether the underlying type u is a map or slice type,
+// as the argument of clear must be.
+func isClearable(u types.Type) bool {
+	switch u.(type) {
+	case *types.Map, *types.Slice:
+		return true
+	}
+	return false
+}
+
+// typeSetAll reports whether pred holds for the underlying type of every type
+// in the type set of t: for t itself, or, if t is a type parameter, for the
+// types that its constraint permits. A constraint that doesn't restrict the
+// types, such as any, permits too many.
+func typeSetAll(t types.Type, pred func(types.Type) bool) bool {
+	tp, ok := t.(*types.TypeParam)
+	if !ok {
+		return pred(t.Underlying())
+	}
+	iface, ok := tp.Constraint().Underlying().(*types.Interface)
+	return ok && interfaceTypeSetAll(iface, pred)
+}
+
+// interfaceTypeSetAll is like typeSetAll, for the type set of the interface
+// iface. As the type set is the intersection of those of the embedded
+// elements, it suffices that pred holds for one of them.
+func interfaceTypeSetAll(iface *types.Interface, pred func(types.Type) bool) bool {
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		switch e := iface.EmbeddedType(i).(type) {
+		case *types.Union:
+			all := e.Len() > 0
+			for j := 0; j < e.Len(); j++ {
+				all = all && typeSetAll(e.Term(j).Type(), pred)
+			}
+			if all {
+				return true
+			}
+		default:
+			if inner, ok := e.Underlying().(*types.Interface); ok {
+				if interfaceTypeSetAll(inner, pred) {
+					return true
+				}
+			} else if typeSetAll(e, pred) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// excludesVersion reports whether the build constraint expr excludes the Go
+// version v, and every later version, whatever the other build tags.
+func excludesVersion(expr constraint.Expr, v string) bool {
+	if expr == nil {
+		return false
+	}
+	// The value of expr changes only at the versions that it mentions, so
+	// it suffices to check v and the later versions that it mentions.
+	versions := []string{v}
+	var tags []string
+	seen := make(map[string]bool)
+	walkTags(expr, func(tag string) {
+		if seen[tag] {
+			return
+		}
+		seen[tag] = true
+		if isGoVersionTag(tag) {
+			if version.Compare(tag, v) > 0 {
+				versions = append(versions, tag)
+			}
+		} else {
+			tags = append(tags, tag)
+		}
+	})
+	if len(tags) > 10 {
+		return false // too many combinations to check
+	}
+	for _, goVersion := range versions {
+		for set := 0; set < 1<<len(tags); set++ {
+			ok := expr.Eval(func(tag string) bool {
+				if isGoVersionTag(tag) {
+					return version.Compare(tag, goVersion) <= 0
+				}
+				for i, t := range tags {
+					if t == tag {
+						return set&(1<<i) != 0
+					}
+				}
+				return false
+			})
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// walkTags calls f for each build tag in expr.
+func walkTags(expr constraint.Expr, f func(tag string)) {
+	switch x := expr.(type) {
+	case *constraint.TagExpr:
+		f(x.Tag)
+	case *constraint.NotExpr:
+		walkTags(x.X, f)
+	case *constraint.AndExpr:
+		walkTags(x.X, f)
+		walkTags(x.Y, f)
+	case *constraint.OrExpr:
+		walkTags(x.X, f)
+		walkTags(x.Y, f)
+	}
+}
+
+// isGoVersionTag reports whether tag is a Go version build tag, e.g.
+// "go1.21".
+func isGoVersionTag(tag string) bool {
+	return strings.HasPrefix(tag, "go1.") && version.IsValid(tag)
+}
+
+// deletePolyfillFix returns a suggested fix that deletes the obsolete polyfill
+// of obj, the declaration that spans node, so that its uses in files refer to
+// the predeclared identifier instead. doc is the doc comment of the
+// declaration, if any, which is deleted with it, and prev, if valid, is the
+// end of the syntax before it, such as the opening parenthesis of a grouped
+// declaration: unless they share a line, the indentation of the declaration is
+// deleted too. It returns nil if a use wouldn't compile with the builtin
+// function, which can only be called, without type arguments or a spread
+// argument.
+func deletePolyfillFix(fset *token.FileSet, pkg *pkgInfo, files []*ast.File, obj types.Object, node ast.Node, doc *ast.CommentGroup, prev token.Pos) *analysis.SuggestedFix {
+	if obj == nil {
+		return nil
+	}
+	if _, ok := obj.(*types.Func); ok {
+		called := make(map[*ast.Ident]bool)
+		for _, f := range files {
+			ast.Inspect(f, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok && !call.Ellipsis.IsValid() {
+					if id, ok := ast.Unparen(call.Fun).(*ast.Ident); ok {
+						called[id] = true
+					}
+				}
+				return true
+			})
+		}
+		for _, id := range pkg.uses(obj) {
+			if !called[id] {
+				return nil
+			}
+		}
+	}
+
+	pos, end := node.Pos(), node.End()
+	if doc != nil {
+		pos = doc.Pos()
+	}
+	tf := fset.File(pos)
+	if line := tf.Line(pos); !prev.IsValid() || tf.Line(prev) < line {
+		pos = tf.LineStart(line)
+	}
+	// Delete the rest of the last line, and a blank line after it.
+	if line := tf.Line(end); line < tf.LineCount() {
+		end = tf.LineStart(line + 1)
+		if line+2 <= tf.LineCount() && tf.LineStart(line+2)-end == 1 {
+			end = tf.LineStart(line + 2)
+		}
+	}
+	return &analysis.SuggestedFix{
+		Message:   fmt.Sprintf("Delete obsolete polyfill %s", obj.Name()),
+		TextEdits: []analysis.TextEdit{{Pos: pos, End: end}},
+	}
+}
@@ -1,13 +1,23 @@
 // Package predeclared provides a static analysis (used by the predeclared command)
 // that can detect declarations in Go code that shadow one of Go's predeclared identifiers.
+//
+// Other analyzers can build on the analysis by requiring Analyzer and using
+// its Result. Programs that don't use the analysis framework can call Check
+// instead.
 package predeclared
 
 import (
 	"fmt"
 	"go/ast"
-	"go/doc"
 	"go/token"
+	"go/types"
+	"go/version"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 
 	"golang.org/x/tools/go/analysis"
 )
@@ -15,90 +25,633 @@ import (
 // Flag names used by the analyzer. They are exported for use by analyzer
 // driver programs.
 const (
-	IgnoreFlag    = "ignore"
-	QualifiedFlag = "q"
-)
+	IgnoreFlag           = "ignore"
+	OnlyFlag             = "only"
+	QualifiedFlag        = "q"
+	PreciseFlag          = "precise"
+	UpgradeFlag          = "upgrade"
+	RenameFlag           = "rename"
+	RenameFileFlag       = "rename-file"
+	FixPackagesFlag      = "fix-package-names"
+	DryRunFlag           = "dry-run"
+	NoConfigFlag         = "no-config"
+	PrintConfigFlag      = "print-config"
+	EnableFlag           = "enable"
+	DisableFlag          = "disable"
+	AuditFlag            = "audit"
+	ExcludeFlag          = "exclude"
+	IncludeGeneratedFlag = "include-generated"
+	IncludeVendoredFlag  = "include-vendored"
+	SeverityFlag         = "severity"
+	SeveritiesFlag       = "severities"
+	SkipBodilessFlag     = "skip-bodiless"
+	ExportedParamsFlag   = "exported-params"
+	ReferencedParamsFlag = "referenced-params"
+	MinRiskFlag          = "min-risk"
+	IncludePolyfillsFlag = "include-polyfills"
 
-var (
-	fIgnore    string
-	fQualified bool
+	// Prefixes of the flags that amend the settings for test code, such as
+	// -test-ignore or -example-severity. Each of IgnoreFlag, EnableFlag,
+	// DisableFlag and SeverityFlag has a flag with each prefix.
+	TestFlagPrefix         = "test-"
+	ExternalTestFlagPrefix = "xtest-"
+	ExampleFlagPrefix      = "example-"
 )
 
-func init() {
-	Analyzer.Flags.StringVar(&fIgnore, IgnoreFlag, "", "comma-separated list of predeclared identifiers to not report on")
-	Analyzer.Flags.BoolVar(&fQualified, QualifiedFlag, false, "include method names and field names (i.e., qualified names) in checks")
+// Analyzer is the default instance of the analyzer. It is configured through
+// its flags. Its result is a *Result.
+var Analyzer = newAnalyzer(new(Config), true)
+
+// NewAnalyzer returns an analyzer configured by cfg. Any number of analyzers
+// may be created, configured differently, and run concurrently. Each has its
+// own flags, whose defaults are taken from cfg. To run several of them with
+// one driver program, give each a distinct cfg.Name.
+//
+// Unlike Analyzer, the analyzers returned by NewAnalyzer don't use facts, so
+// that several of them can be run by one driver program. As a result, their
+// reports of unaliased imports don't mention whether the imported package's
+// package clause is reported too, and come without fixes.
+func NewAnalyzer(cfg Config) *analysis.Analyzer {
+	return newAnalyzer(&cfg, false)
 }
 
-var Analyzer = &analysis.Analyzer{
-	Name: "predeclared",
-	Doc:  "find code that shadows one of Go's predeclared identifiers",
-	Run:  run,
+func newAnalyzer(c *Config, facts bool) *analysis.Analyzer {
+	name := c.Name
+	if name == "" {
+		name = "predeclared"
+	}
+	a := &analysis.Analyzer{
+		Name: name,
+		Doc:  "find code that shadows one of Go's predeclared identifiers",
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			return run(pass, *c)
+		},
+		ResultType: reflect.TypeOf(new(Result)),
+	}
+	if facts {
+		a.FactTypes = []analysis.Fact{new(packageNameFact)}
+	}
+	c.registerFlags(&a.Flags)
+	return a
 }
 
-func run(pass *analysis.Pass) (interface{}, error) {
-	cfg := newConfig(fIgnore, fQualified)
+// printConfigMu serializes the printing of configurations by concurrent
+// passes.
+var printConfigMu sync.Mutex
+
+func run(pass *analysis.Pass, c Config) (interface{}, error) {
+	cfg, err := loadConfig(c, packageDir(pass.Fset, pass.Files))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.printConfig {
+		printConfigMu.Lock()
+		defer printConfigMu.Unlock()
+		return new(Result), writeEffectiveConfig(os.Stdout, pass.Pkg.Path(), cfg)
+	}
+	pkg := &pkgInfo{
+		pkg:  pass.Pkg,
+		info: pass.TypesInfo,
+	}
+	if pass.Module != nil {
+		// Drivers copy the go directive of go.mod, e.g. "1.21".
+		cfg.goVersion = normalizeGoVersion(pass.Module.GoVersion)
+		pkg.module = pass.Module.Path
+	}
+	cfg.vendored = isVendored(pass.Pkg.Path())
+	cfg.directives = newDirectiveSet(pass.Fset, pass.Files, pass.Report)
+	cfg.noteGenerated(pass.Fset, pass.Files)
+	cfg.setFiles(pass.Fset, pass.Files)
+	if len(pass.Analyzer.FactTypes) > 0 {
+		pkg.importFact = pass.ImportPackageFact
+		if name := pass.Pkg.Name(); name != "main" && !cfg.precise && cfg.enabled(PackageName) && isPredeclared(name, version.Lang(cfg.goVersion)) {
+			if cfg.selected(PackageName, name, nil) && !clauseSuppressed(cfg.directives, name) {
+				pass.ExportPackageFact(&packageNameFact{Name: name})
+			}
+		}
+	}
+	result := new(Result)
 	for _, file := range pass.Files {
-		processFile(pass.Report, cfg, pass.Fset, file)
+		for _, issue := range processFile(pass.Report, cfg, pass.Fset, file, pkg) {
+			result.Decls = append(result.Decls, issue.decl)
+		}
+	}
+	if cfg.audit {
+		auditDirectives(pass, cfg)
+		if !c.NoConfigFile {
+			if err := auditIgnoreEntries(pass, c, cfg, packageDir(pass.Fset, pass.Files)); err != nil {
+				return nil, err
+			}
+		}
 	}
-	return nil, nil
+	return result, nil
 }
 
-type config struct {
-	qualified     bool
-	ignoredIdents map[string]struct{}
+// A packageNameFact is exported for a package whose package clause name is a
+// predeclared identifier, and which therefore shadows that identifier in every
+// file that imports the package without an alias. The package clause itself
+// is reported while analyzing the package.
+type packageNameFact struct {
+	Name string
 }
 
-func newConfig(ignore string, qualified bool) *config {
-	cfg := &config{
-		qualified:     qualified,
-		ignoredIdents: map[string]struct{}{},
+func (*packageNameFact) AFact() {}
+
+func (f *packageNameFact) String() string { return "predeclared package name " + f.Name }
+
+// A pkgInfo holds type information about the package whose files are being
+// checked.
+type pkgInfo struct {
+	pkg        *types.Package
+	info       *types.Info
+	module     string                                   // module path, if known
+	importFact func(*types.Package, analysis.Fact) bool // may be nil
+
+	// refs holds the references to each object, namedRefs the references
+	// with each name, and universeRefs the positions of the references to
+	// each universe object. They're computed on first use.
+	refs         map[types.Object][]*ast.Ident
+	namedRefs    map[string][]*ast.Ident
+	universeRefs map[types.Object][]token.Pos
+}
+
+// countRefs computes p.refs, p.namedRefs and p.universeRefs, if it hasn't
+// yet.
+func (p *pkgInfo) countRefs() {
+	if p.refs != nil {
+		return
 	}
-	for _, s := range strings.Split(ignore, ",") {
-		ident := strings.TrimSpace(s)
-		if ident == "" {
-			continue
+	p.refs = make(map[types.Object][]*ast.Ident)
+	p.namedRefs = make(map[string][]*ast.Ident)
+	p.universeRefs = make(map[types.Object][]token.Pos)
+	for ident, obj := range p.info.Uses {
+		p.refs[obj] = append(p.refs[obj], ident)
+		p.namedRefs[ident.Name] = append(p.namedRefs[ident.Name], ident)
+		if obj.Parent() == types.Universe {
+			p.universeRefs[obj] = append(p.universeRefs[obj], ident.Pos())
 		}
-		cfg.ignoredIdents[ident] = struct{}{}
 	}
-	return cfg
 }
 
-type issue struct {
-	ident *ast.Ident
-	kind  string
-	fset  *token.FileSet
+// referenced reports whether obj is referred to anywhere in the package.
+func (p *pkgInfo) referenced(obj types.Object) bool {
+	p.countRefs()
+	return len(p.refs[obj]) > 0
 }
 
-func (i issue) String() string {
-	pos := i.fset.Position(i.ident.Pos())
-	return fmt.Sprintf("%s: %s %s has same name as predeclared identifier", pos, i.kind, i.ident.Name)
+// related returns the related information of the report of the declaration
+// of obj, of the given kind, which shadows the predeclared identifier: the
+// extent of the scope in which the declaration shadows it, unless that's the
+// package scope, and the references to the declaration anywhere in the
+// package, in order of file name and position.
+func (p *pkgInfo) related(fset *token.FileSet, obj types.Object, kind Kind, shadowed predeclaredIdent) []analysis.RelatedInformation {
+	if obj == nil {
+		return nil
+	}
+	var related []analysis.RelatedInformation
+	if scope := obj.Parent(); hiddenUniverseObject(obj) != nil && scope != p.pkg.Scope() && scope.End().IsValid() {
+		related = append(related, analysis.RelatedInformation{
+			Pos:     scope.Pos(),
+			End:     scope.End(),
+			Message: fmt.Sprintf("scope in which predeclared %s %s is shadowed", shadowed.category, shadowed.name),
+		})
+	}
+	p.countRefs()
+	refs := append([]*ast.Ident(nil), p.refs[obj]...)
+	sort.Slice(refs, func(i, j int) bool {
+		pi, pj := fset.Position(refs[i].Pos()), fset.Position(refs[j].Pos())
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		return pi.Offset < pj.Offset
+	})
+	for _, ref := range refs {
+		related = append(related, analysis.RelatedInformation{
+			Pos:     ref.Pos(),
+			End:     ref.End(),
+			Message: fmt.Sprintf("reference to %s %s", kind, obj.Name()),
+		})
+	}
+	return related
+}
+
+// inModule reports whether the package with the given import path belongs to
+// the module of the package being checked, as far as can be told.
+func (p *pkgInfo) inModule(path string) bool {
+	return p.module == "" || path == p.module || strings.HasPrefix(path, p.module+"/")
+}
+
+// An Issue is a declaration that has the same name as a predeclared
+// identifier.
+type Issue struct {
+	Kind Kind
+	// Ident is the declared identifier. It is nil for an ImplicitImportName,
+	// which is declared by an import path rather than an identifier.
+	Ident *ast.Ident
+	// Name is the declared name.
+	Name string
+	// Pos is the position of the declaration.
+	Pos token.Position
+	// Category is the category of the predeclared identifier that is
+	// shadowed.
+	Category Category
+	// Class is the class of the shadowed identifier, which determines the
+	// default severity of the issue.
+	Class Class
+	// Severity is the severity of the issue.
+	Severity Severity
+	// Risk is the risk score of the issue, from 0 to 100, or -1 if it's
+	// unknown for lack of type information.
+	Risk int
+	// Message describes the issue, as diagnostics do.
+	Message string
+
+	decl *Declaration
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Pos, i.Message)
+}
+
+// Check returns the declarations in files that have the same name as a
+// predeclared identifier, according to cfg. The files needn't be type-checked:
+// Check uses only syntax. As a result, unaliased imports are never reported,
+// issues have no risk scores, and cfg.Precise, cfg.MinRisk and the fields of
+// cfg that concern suggested fixes are ignored. The module's Go version is
+// taken to be the latest, though //go:build lines in files are respected. As
+// the import path of the package is unknown, its files are checked even if
+// it's vendored.
+//
+// Unless cfg.NoConfigFile is set, the configuration files found by walking up
+// from the directory of the first file apply as well.
+func Check(fset *token.FileSet, files []*ast.File, cfg Config) ([]Issue, error) {
+	cfg.Precise = false
+	cfg.MinRisk = 0
+	c, err := loadConfig(cfg, packageDir(fset, files))
+	if err != nil {
+		return nil, err
+	}
+	c.directives = newDirectiveSet(fset, files, func(analysis.Diagnostic) {})
+	c.noteGenerated(fset, files)
+	c.setFiles(fset, files)
+	var issues []Issue
+	for _, file := range files {
+		issues = append(issues, processFile(func(analysis.Diagnostic) {}, c, fset, file, nil)...)
+	}
+	return issues, nil
 }
 
-func processFile(report func(analysis.Diagnostic), cfg *config, fset *token.FileSet, file *ast.File) []issue { // nolint: gocyclo
-	var issues []issue
+// packageDir returns the directory of the package made up of files, or the
+// empty string if it's unknown.
+func packageDir(fset *token.FileSet, files []*ast.File) string {
+	if len(files) == 0 {
+		return ""
+	}
+	return filepath.Dir(fset.File(files[0].Package).Name())
+}
+
+// processFile reports the declarations in file that have the same name as a
+// predeclared identifier. In precise mode, pkg must hold type information for
+// the file's package; otherwise pkg may be nil, in which case unaliased
+// imports aren't checked and no fixes are suggested.
+func processFile(report func(analysis.Diagnostic), cfg *config, fset *token.FileSet, file *ast.File, pkg *pkgInfo) []Issue { // nolint: gocyclo
+	filename := fset.File(file.Package).Name()
+	if cfg.excluded(filename) || !cfg.includeGenerated && ast.IsGenerated(file) {
+		return nil
+	}
+
+	var issues []Issue
+
+	var info *types.Info
+	if pkg != nil {
+		info = pkg.info
+	}
+
+	fileVersion := fileGoVersion(cfg.goVersion, file)
+
+	// dirs are the suppression directives that apply to the file.
+	ds := cfg.directives
+	if ds == nil {
+		ds = newDirectiveSet(fset, []*ast.File{file}, report)
+	}
+	dirs := ds.forFile(file)
+
+	// code is the kind of code in the file, and exampleFunc the Example,
+	// Benchmark or Fuzz function being walked, if any. Each has a policy of
+	// its own.
+	code := codeKindOf(filename, file)
+	var exampleFunc *ast.FuncDecl
+	policyAt := func(pos token.Pos) *policy {
+		if exampleFunc != nil && exampleFunc.Pos() <= pos && pos < exampleFunc.End() {
+			return cfg.examplePolicies[code]
+		}
+		return cfg.codePolicies[code]
+	}
+
+	// clauseFiles are the files of the package whose package clauses are
+	// reported together, at the first of them, with the others as related
+	// information.
+	clauseFiles := cfg.clauseFiles(file)
+	var clauseRelated []analysis.RelatedInformation
+	for _, f := range clauseFiles[1:] {
+		clauseRelated = append(clauseRelated, analysis.RelatedInformation{
+			Pos:     f.Name.Pos(),
+			End:     f.Name.End(),
+			Message: fmt.Sprintf("package clause %s in another file of the package", f.Name.Name),
+		})
+	}
 
-	maybeReport := func(x *ast.Ident, kind string) {
-		if _, isIgnored := cfg.ignoredIdents[x.Name]; !isIgnored && doc.IsPredeclared(x.Name) {
-			report(analysis.Diagnostic{
-				Pos:     x.Pos(),
-				End:     x.End(),
-				Message: fmt.Sprintf("%s %s has same name as predeclared identifier", kind, x.Name),
+	// implicitObjs holds, for identifiers that have no entry in info.Defs,
+	// the objects they implicitly declare.
+	implicitObjs := make(map[*ast.Ident][]types.Object)
+
+	// maybeReportAt reports the declaration of name at node, which declares
+	// obj, if known. In precise mode, hidden is the universe object that the
+	// declaration hides, and nothing is reported if it is nil. note, if
+	// non-empty, is appended to the message. fix, if non-nil, is suggested as
+	// a fix.
+	maybeReportAt := func(node ast.Node, name string, kind Kind, obj, hidden types.Object, note string, fix func() *analysis.SuggestedFix) {
+		pol := policyAt(node.Pos())
+		if !pol.enabled(kind) {
+			return
+		}
+		p, isPredeclared := lookupPredeclared(name, fileVersion)
+		if cfg.upgradeVersion != "" {
+			// Report what isn't predeclared now, but will be after the upgrade.
+			if isPredeclared {
+				return
+			}
+			p, isPredeclared = lookupPredeclared(name, cfg.upgradeVersion)
+		}
+		if !isPredeclared || cfg.precise && hidden == nil {
+			return
+		}
+		// risk is the declaration's risk score, if there is type
+		// information.
+		risk := -1
+		if info != nil {
+			risk = riskScore(fset, file, pkg, obj, name, node.Pos())
+			if risk < cfg.minRisk {
+				return
+			}
+		}
+		// The declaration would be reported, but for the ignored
+		// identifiers and directives.
+		if !pol.selected(kind, name, cfg.usedIgnores) {
+			return
+		}
+		if suppressed(dirs, kind, name, fset.Position(node.Pos()).Line) {
+			return
+		}
+		var message string
+		switch {
+		case cfg.upgradeVersion != "":
+			message = fmt.Sprintf("%s %s will shadow predeclared %s %s in %s", kind, name, p.category, p.name, cfg.upgradeVersion)
+		case cfg.precise:
+			message = fmt.Sprintf("%s %s shadows predeclared %s %s", kind, name, p.category, p.name)
+		default:
+			message = fmt.Sprintf("%s %s has same name as predeclared identifier", kind, name)
+		}
+		if note != "" {
+			message += " (" + note + ")"
+		}
+		if cfg.minRisk > 0 && risk >= 0 {
+			message += fmt.Sprintf(" (risk %d)", risk)
+		}
+		class := classOf(p)
+		severity := pol.severity
+		if severity == 0 {
+			severity = cfg.severities[class]
+		}
+		var fixes []analysis.SuggestedFix
+		if fix != nil {
+			if f := fix(); f != nil && !cfg.editsExcluded(fset, f) {
+				if cfg.dryRun {
+					message += fmt.Sprintf(" (dry run: fix %q would edit %s)", f.Message, describeEdits(fset, f.TextEdits))
+				} else {
+					fixes = append(fixes, *f)
+				}
+			}
+		}
+		diag := analysis.Diagnostic{
+			Pos:            node.Pos(),
+			End:            node.End(),
+			Category:       string(class),
+			Message:        message,
+			SuggestedFixes: fixes,
+		}
+		if info != nil {
+			diag.Related = pkg.related(fset, obj, kind, p)
+		}
+		if kind == PackageName {
+			diag.Related = append(diag.Related, clauseRelated...)
+		}
+		report(diag)
+		ident, _ := node.(*ast.Ident)
+		decl := &Declaration{
+			Kind:     kind,
+			Name:     name,
+			Ident:    ident,
+			Pos:      node.Pos(),
+			End:      node.End(),
+			Object:   obj,
+			Shadowed: types.Universe.Lookup(name),
+			Class:    class,
+			Severity: severity,
+			Risk:     risk,
+		}
+		if obj != nil {
+			decl.Scope = obj.Parent()
+		}
+		issues = append(issues, Issue{
+			Kind:     kind,
+			Ident:    ident,
+			Name:     name,
+			Pos:      fset.Position(node.Pos()),
+			Category: p.category,
+			Class:    class,
+			Severity: severity,
+			Risk:     risk,
+			Message:  message,
+			decl:     decl,
+		})
+	}
+
+	maybeReport := func(x *ast.Ident, kind Kind) {
+		if info == nil {
+			maybeReportAt(x, x.Name, kind, nil, nil, "", nil)
+			return
+		}
+		obj := info.Defs[x]
+		implicits := implicitObjs[x]
+		if obj == nil && len(implicits) > 0 {
+			obj, implicits = implicits[0], implicits[1:]
+		}
+		fix := func() *analysis.SuggestedFix {
+			preferred, _ := cfg.renames.lookup(kind, x.Name)
+			return renameFix(pkg, x, obj, implicits, preferred)
+		}
+		maybeReportAt(x, x.Name, kind, obj, hiddenUniverseObject(obj), "", fix)
+	}
+
+	clauseFix := func() *analysis.SuggestedFix {
+		if !cfg.fixPackageNames || pkg == nil {
+			return nil
+		}
+		newName := packageRename(pkg.pkg.Path(), file.Name.Name)
+		fix := &analysis.SuggestedFix{
+			Message: fmt.Sprintf("Rename package %s to %s", file.Name.Name, newName),
+		}
+		for _, f := range clauseFiles {
+			fix.TextEdits = append(fix.TextEdits, analysis.TextEdit{
+				Pos:     f.Name.Pos(),
+				End:     f.Name.End(),
+				NewText: []byte(newName),
 			})
-			issues = append(issues, issue{x, kind, fset})
+		}
+		return fix
+	}
+
+	reportFieldList := func(fl *ast.FieldList, kind Kind) {
+		if fl == nil {
+			return
+		}
+		for _, field := range fl.List {
+			for _, name := range field.Names {
+				maybeReport(name, kind)
+			}
+		}
+	}
+
+	// reportParams reports the parameters or named results in fl, of the
+	// given kind, subject to the policy for parameters.
+	reportParams := func(fl *ast.FieldList, kind Kind, sig signature) {
+		if fl == nil || sig.bodiless && cfg.skipBodiless {
+			return
+		}
+		if sig.exported {
+			switch cfg.exportedParams {
+			case "skip":
+				return
+			case "separate":
+				kind = ExportedParam
+			}
+		}
+		for _, field := range fl.List {
+			for _, name := range field.Names {
+				if cfg.referencedParams && info != nil && !pkg.referenced(info.Defs[name]) {
+					continue
+				}
+				maybeReport(name, kind)
+			}
 		}
 	}
 
 	seenValueSpecs := make(map[*ast.ValueSpec]bool)
+	seenAssignStmts := make(map[*ast.AssignStmt]bool)
+	// paramKinds holds the kind of the parameters of function types that
+	// belong to declarations, function literals and interface methods.
+	// Parameters of other function types are FuncTypeParams.
+	paramKinds := make(map[*ast.FuncType]Kind)
+	// signatures holds what the policy for parameters needs to know about
+	// the function types that belong to declarations, function literals and
+	// interface methods. Other function types are bodiless.
+	signatures := make(map[*ast.FuncType]signature)
+	// packageDecls holds the package-level declarations.
+	packageDecls := make(map[ast.Decl]bool)
+	for _, decl := range file.Decls {
+		packageDecls[decl] = true
+	}
+	// api reports whether the file may declare exported API, and
+	// exportedTypes holds the types of its exported package-level type
+	// declarations.
+	api := file.Name.Name != "main" && code == productionCode
+	exportedTypes := make(map[ast.Expr]bool)
+	// packageTypeSpecs maps the package-level type specs to their
+	// declarations.
+	packageTypeSpecs := make(map[*ast.TypeSpec]*ast.GenDecl)
+	for _, decl := range file.Decls {
+		if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.TYPE {
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.TypeSpec)
+				packageTypeSpecs[spec] = decl
+				if api && spec.Name.IsExported() {
+					exportedTypes[spec.Type] = true
+				}
+			}
+		}
+	}
 
-	// TODO: consider deduping package name issues for files in the
-	// same directory.
-	maybeReport(file.Name, "package name")
+	// reportPackageDecl reports the package-level declaration decl of name,
+	// unless it's a polyfill of a newer predeclared identifier. An obsolete
+	// polyfill is reported as such, with a fix that deletes node, which
+	// spans decl, and its doc comment doc; prev is as for deletePolyfillFix.
+	reportPackageDecl := func(name *ast.Ident, kind Kind, decl, node ast.Node, doc *ast.CommentGroup, prev token.Pos) {
+		switch polyfillStatusOf(fileVersion, file, info, name, decl) {
+		case polyfill:
+			if !cfg.includePolyfills {
+				return
+			}
+		case obsoletePolyfill:
+			p := predeclaredByName[name.Name]
+			note := fmt.Sprintf("obsolete polyfill of the %s added in %s", p.category, p.since)
+			if info == nil {
+				maybeReportAt(name, name.Name, kind, nil, nil, note, nil)
+				return
+			}
+			obj := info.Defs[name]
+			files := cfg.files
+			if files == nil {
+				files = []*ast.File{file}
+			}
+			fix := func() *analysis.SuggestedFix {
+				return deletePolyfillFix(fset, pkg, files, obj, node, doc, prev)
+			}
+			maybeReportAt(name, name.Name, kind, obj, hiddenUniverseObject(obj), note, fix)
+			return
+		}
+		maybeReport(name, kind)
+	}
+
+	// The package clause declares nothing in any scope, so it isn't reported
+	// in precise mode. The unaliased imports of the package are, in the
+	// importing files.
+	if clauseFiles[0] == file && !cfg.precise {
+		maybeReportAt(file.Name, file.Name.Name, PackageName, nil, nil, "", clauseFix)
+	}
 
 	for _, spec := range file.Imports {
-		if spec.Name == nil {
+		if spec.Name != nil {
+			if info == nil {
+				maybeReportAt(spec.Name, spec.Name.Name, ImportName, nil, nil, "", nil)
+				continue
+			}
+			pkgName, _ := info.Defs[spec.Name].(*types.PkgName)
+			fix := func() *analysis.SuggestedFix { return aliasFix(pkg, spec, pkgName) }
+			maybeReportAt(spec.Name, spec.Name.Name, ImportName, pkgName, hiddenUniverseObject(pkgName), "", fix)
 			continue
 		}
-		maybeReport(spec.Name, "import name")
+		// An unaliased import declares the imported package's own name.
+		if info == nil {
+			continue
+		}
+		if pkgName, ok := info.Implicits[spec].(*types.PkgName); ok {
+			imported := pkgName.Imported()
+			// clauseReported is whether the package clause of the imported
+			// package is reported, and so renamed by its fix.
+			clauseReported := pkg.importFact != nil && pkg.importFact(imported, new(packageNameFact))
+			var note string
+			if clauseReported {
+				note = "also reported at the package clause of " + imported.Path()
+			}
+			var fix func() *analysis.SuggestedFix
+			if cfg.fixPackageNames && clauseReported && pkg.inModule(imported.Path()) {
+				fix = func() *analysis.SuggestedFix {
+					return importerFix(pkg, spec, pkgName, packageRename(imported.Path(), imported.Name()))
+				}
+			}
+			maybeReportAt(spec.Path, pkgName.Name(), ImplicitImportName, pkgName, hiddenUniverseObject(pkgName), note, fix)
+		}
 	}
 
 	// Handle declarations and fields.
@@ -106,12 +659,18 @@ func processFile(report func(analysis.Diagnostic), cfg *config, fset *token.File
 	ast.Inspect(file, func(n ast.Node) bool {
 		switch x := n.(type) {
 		case *ast.GenDecl:
-			var kind string
+			var kind Kind
 			switch x.Tok {
 			case token.CONST:
-				kind = "const"
+				kind = LocalConst
+				if packageDecls[x] {
+					kind = Const
+				}
 			case token.VAR:
-				kind = "variable"
+				kind = LocalVar
+				if packageDecls[x] {
+					kind = Var
+				}
 			default:
 				return true
 			}
@@ -125,71 +684,160 @@ func processFile(report func(analysis.Diagnostic), cfg *config, fset *token.File
 			}
 			return true
 		case *ast.TypeSpec:
-			maybeReport(x.Name, "type")
+			if decl := packageTypeSpecs[x]; decl == nil {
+				maybeReport(x.Name, Type)
+			} else if decl.Lparen.IsValid() {
+				// Delete only the spec from the group.
+				prev := decl.Lparen
+				for _, spec := range decl.Specs {
+					if spec == x {
+						break
+					}
+					prev = spec.End()
+					if c := spec.(*ast.TypeSpec).Comment; c != nil {
+						prev = c.End()
+					}
+				}
+				reportPackageDecl(x.Name, Type, x, x, x.Doc, prev)
+			} else {
+				reportPackageDecl(x.Name, Type, x, decl, decl.Doc, token.NoPos)
+			}
+			reportFieldList(x.TypeParams, TypeParam)
 			return true
 		case *ast.StructType:
-			if cfg.qualified && x.Fields != nil {
+			if x.Fields != nil {
 				for _, field := range x.Fields.List {
 					for _, name := range field.Names {
-						maybeReport(name, "field")
+						maybeReport(name, Field)
+					}
+					if len(field.Names) == 0 {
+						if name := embeddedFieldName(field.Type); name != nil {
+							maybeReport(name, EmbeddedField)
+						}
 					}
 				}
 			}
 			return true
 		case *ast.InterfaceType:
-			if cfg.qualified && x.Methods != nil {
+			if x.Methods != nil {
 				for _, meth := range x.Methods.List {
+					// Embedded interfaces and type-set terms (~int | string)
+					// have no names; only methods declare anything.
 					for _, name := range meth.Names {
-						maybeReport(name, "method")
+						maybeReport(name, Method)
+					}
+					if ft, ok := meth.Type.(*ast.FuncType); ok {
+						paramKinds[ft] = InterfaceMethodParam
+						signatures[ft] = signature{
+							bodiless: true,
+							exported: exportedTypes[x] && len(meth.Names) > 0 && meth.Names[0].IsExported(),
+						}
 					}
 				}
 			}
 			return true
 		case *ast.FuncDecl:
+			if isExampleFunc(code, x) {
+				exampleFunc = x
+			}
+			signatures[x.Type] = signature{
+				bodiless: x.Body == nil,
+				exported: api && isExportedFunc(x),
+			}
 			if x.Recv == nil {
 				// it's a function
-				maybeReport(x.Name, "function")
+				reportPackageDecl(x.Name, Func, x, x, x.Doc, token.NoPos)
+				paramKinds[x.Type] = Param
 			} else {
 				// it's a method
-				if cfg.qualified {
-					maybeReport(x.Name, "method")
-				}
+				maybeReport(x.Name, Method)
+				paramKinds[x.Type] = MethodParam
 			}
 			// add receivers idents
 			if x.Recv != nil {
 				for _, field := range x.Recv.List {
 					for _, name := range field.Names {
-						maybeReport(name, "receiver")
+						maybeReport(name, Receiver)
+					}
+					// The type arguments in a receiver such as (s Set[K, V])
+					// declare the method's type parameters.
+					for _, ident := range receiverTypeParams(field.Type) {
+						maybeReport(ident, TypeParam)
 					}
 				}
 			}
 			// Params and Results will be checked in the *ast.FuncType case.
 			return true
+		case *ast.FuncLit:
+			paramKinds[x.Type] = ClosureParam
+			signatures[x.Type] = signature{}
+			return true
 		case *ast.FuncType:
+			// add type params idents
+			reportFieldList(x.TypeParams, TypeParam)
 			// add params idents
-			for _, field := range x.Params.List {
-				for _, name := range field.Names {
-					maybeReport(name, "param")
-				}
+			paramKind, ok := paramKinds[x]
+			if !ok {
+				paramKind = FuncTypeParam
 			}
+			sig, ok := signatures[x]
+			if !ok {
+				sig = signature{bodiless: true, exported: exportedTypes[x]}
+			}
+			reportParams(x.Params, paramKind, sig)
 			// add returns idents
-			if x.Results != nil {
-				for _, field := range x.Results.List {
-					for _, name := range field.Names {
-						maybeReport(name, "named return")
+			reportParams(x.Results, NamedReturn, sig)
+			return true
+		case *ast.LabeledStmt:
+			maybeReport(x.Label, Label)
+			return true
+		case *ast.RangeStmt:
+			// for key, value := range x {}
+			if x.Tok == token.DEFINE {
+				for _, expr := range []ast.Expr{x.Key, x.Value} {
+					if ident, ok := expr.(*ast.Ident); ok {
+						maybeReport(ident, RangeVar)
 					}
 				}
 			}
 			return true
-		case *ast.LabeledStmt:
-			maybeReport(x.Label, "label")
+		case *ast.TypeSwitchStmt:
+			// switch v := x.(type) {}
+			// The guard declares a distinct implicit object in each case clause,
+			// but they all share the one identifier, so report it once.
+			if assign, ok := x.Assign.(*ast.AssignStmt); ok && assign.Tok == token.DEFINE {
+				seenAssignStmts[assign] = true
+				for _, expr := range assign.Lhs {
+					if ident, ok := expr.(*ast.Ident); ok {
+						if info != nil {
+							for _, clause := range x.Body.List {
+								if obj := info.Implicits[clause]; obj != nil {
+									implicitObjs[ident] = append(implicitObjs[ident], obj)
+								}
+							}
+						}
+						maybeReport(ident, TypeSwitchVar)
+					}
+				}
+			}
+			return true
+		case *ast.CommClause:
+			// case v := <-ch:
+			if assign, ok := x.Comm.(*ast.AssignStmt); ok && assign.Tok == token.DEFINE {
+				seenAssignStmts[assign] = true
+				for _, expr := range assign.Lhs {
+					if ident, ok := expr.(*ast.Ident); ok {
+						maybeReport(ident, SelectVar)
+					}
+				}
+			}
 			return true
 		case *ast.AssignStmt:
 			// We only care about short variable declarations, which use token.DEFINE.
-			if x.Tok == token.DEFINE {
+			if x.Tok == token.DEFINE && !seenAssignStmts[x] {
 				for _, expr := range x.Lhs {
 					if ident, ok := expr.(*ast.Ident); ok {
-						maybeReport(ident, "variable")
+						maybeReport(ident, LocalVar)
 					}
 				}
 			}
@@ -201,3 +849,103 @@ func processFile(report func(analysis.Diagnostic), cfg *config, fset *token.File
 
 	return issues
 }
+
+// A signature describes a function type for the policy for parameters.
+type signature struct {
+	bodiless bool // whether the function has no body
+	exported bool // whether the function is part of the package's exported API
+}
+
+// isExportedFunc reports whether the package-level function or method
+// declared by decl has an exported name, as has the receiver type of a
+// method.
+func isExportedFunc(decl *ast.FuncDecl) bool {
+	if !decl.Name.IsExported() {
+		return false
+	}
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return true
+	}
+	typ := decl.Recv.List[0].Type
+	for {
+		switch x := typ.(type) {
+		case *ast.ParenExpr:
+			typ = x.X
+			continue
+		case *ast.StarExpr:
+			typ = x.X
+			continue
+		case *ast.IndexExpr:
+			typ = x.X
+			continue
+		case *ast.IndexListExpr:
+			typ = x.X
+			continue
+		case *ast.Ident:
+			return x.IsExported()
+		}
+		return false
+	}
+}
+
+// embeddedFieldName returns the identifier that names an embedded field of
+// the given type, e.g. T in *pkg.T[int], or nil if there is none.
+func embeddedFieldName(typ ast.Expr) *ast.Ident {
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	switch x := typ.(type) {
+	case *ast.IndexExpr:
+		typ = x.X
+	case *ast.IndexListExpr:
+		typ = x.X
+	}
+	switch x := typ.(type) {
+	case *ast.Ident:
+		return x
+	case *ast.SelectorExpr:
+		return x.Sel
+	}
+	return nil
+}
+
+// receiverTypeParams returns the identifiers declared as type parameters by
+// a method receiver type expression, e.g. K and V in (s *Set[K, V]).
+func receiverTypeParams(typ ast.Expr) []*ast.Ident {
+	if paren, ok := typ.(*ast.ParenExpr); ok {
+		typ = paren.X
+	}
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	var indices []ast.Expr
+	switch x := typ.(type) {
+	case *ast.IndexExpr:
+		indices = []ast.Expr{x.Index}
+	case *ast.IndexListExpr:
+		indices = x.Indices
+	}
+	var idents []*ast.Ident
+	for _, index := range indices {
+		if ident, ok := index.(*ast.Ident); ok {
+			idents = append(idents, ident)
+		}
+	}
+	return idents
+}
+
+// hiddenUniverseObject returns the universe-scope object that obj hides
+// within obj's scope, or nil if obj hides nothing. Objects that are not
+// declared in a scope, such as labels, struct fields, methods, and the
+// package clause name (which has no object), never hide anything.
+func hiddenUniverseObject(obj types.Object) types.Object {
+	if obj == nil || obj.Parent() == nil || obj.Parent() == types.Universe {
+		return nil
+	}
+	if _, ok := obj.(*types.Label); ok {
+		return nil
+	}
+	// Every scope chain ends at the universe scope, so a name declared in
+	// any other scope hides the universe object of the same name.
+	return types.Universe.Lookup(obj.Name())
+}
@@ -2,14 +2,25 @@ package predeclared
 
 import (
 	"bytes"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"go/ast"
+	"go/build/constraint"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 
 	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/go/analysis/multichecker"
 )
 
 func outPath(p string) string { return strings.TrimSuffix(p, ".go") + ".out" }
@@ -27,9 +38,20 @@ got:  %s
 	}
 }
 
+// setupConfig returns the config for the testdata file at path p. The config
+// is set up from the analyzer flags on the file's first line, if it has the
+// form:
+//
+//	//predeclared [flags]
+//
+// In addition to the analyzer flags, the '-go' flag sets the Go version of the
+// file's module.
 func setupConfig(p string) *config {
-	ignore := ""
-	qualified := false
+	var c Config
+	var goVersion string
+	fs := flag.NewFlagSet(p, flag.PanicOnError)
+	c.registerFlags(fs)
+	fs.StringVar(&goVersion, "go", "", "Go version of the module")
 
 	// Get the first line.
 	b, err := ioutil.ReadFile(p)
@@ -43,28 +65,16 @@ func setupConfig(p string) *config {
 	// Does it have the prefix?
 	const prefix = "//predeclared"
 	line := string(b[:idx])
-	if !strings.HasPrefix(line, prefix) {
-		return newConfig(ignore, qualified)
-	} else {
-		line = strings.TrimPrefix(line, prefix)
-	}
-	// Parse.
-	args := strings.Fields(line)
-	for i := 0; i < len(args); {
-		arg := args[i]
-		switch arg {
-		case "-ignore":
-			i++
-			ignore = args[i]
-		case "-q":
-			qualified = true
-		default:
-			panic("unhandled flag")
-		}
-		i++
+	if strings.HasPrefix(line, prefix) {
+		fs.Parse(strings.Fields(strings.TrimPrefix(line, prefix)))
 	}
 
-	return newConfig(ignore, qualified)
+	cfg, err := newConfig(c, nil)
+	if err != nil {
+		panic(err)
+	}
+	cfg.goVersion = normalizeGoVersion(goVersion)
+	return cfg
 }
 
 func TestAll(t *testing.T) {
@@ -77,6 +87,33 @@ func TestAll(t *testing.T) {
 		"testdata/all-q.go",
 		"testdata/no-issues.go",
 		"testdata/no-issues2.go",
+		"testdata/shortdecl.go",
+		"testdata/generics.go",
+		"testdata/precise.go",
+		"testdata/implicit-import.go",
+		"testdata/go-version.go",
+		"testdata/go-version-old.go",
+		"testdata/go-version-build.go",
+		"testdata/upgrade.go",
+		"testdata/dry-run.go",
+		"testdata/kinds.go",
+		"testdata/kinds-enable.go",
+		"testdata/ignore-groups.go",
+		"testdata/only.go",
+		"testdata/suppress.go",
+		"testdata/generated.go",
+		"testdata/generated-include.go",
+		"testdata/exclude.go",
+		"testdata/polyfill.go",
+		"testdata/polyfill-any.go",
+		"testdata/polyfill-incompatible.go",
+		"testdata/polyfill-signature.go",
+		"testdata/polyfill-types.go",
+		"testdata/polyfill-include.go",
+		"testdata/obsolete.go",
+		"testdata/obsolete-build.go",
+		"testdata/polyfill-go120.go",
+		"testdata/polyfill-go122.go",
 	}
 
 	for i, path := range filenames {
@@ -87,6 +124,733 @@ func TestAll(t *testing.T) {
 	}
 }
 
+func TestFixes(t *testing.T) {
+	filenames := []string{
+		"testdata/fix.go",
+		"testdata/fix-renames.go",
+		"testdata/fix-import.go",
+		"testdata/obsolete-fix.go",
+		"testdata/polyfill-go122.go",
+	}
+
+	for i, path := range filenames {
+		if testing.Verbose() {
+			t.Logf("test [%d]: %s", i, path)
+		}
+		runOneFixFile(t, setupConfig(path), path)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	const path = "testdata/example2.go"
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := Check(fset, []*ast.File{file}, Config{Ignore: []string{"copy"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		Kind     Kind
+		Name     string
+		Line     int
+		Category Category
+	}
+	want := []result{
+		{PackageName, "print", 1, CategoryBuiltinFunc},
+		{ImportName, "cap", 3, CategoryBuiltinFunc},
+		{Func, "make", 5, CategoryBuiltinFunc},
+	}
+	var got []result
+	for _, issue := range issues {
+		if issue.Ident == nil || issue.Ident.Name != issue.Name {
+			t.Errorf("%s: Ident = %v, want identifier %s", issue, issue.Ident, issue.Name)
+		}
+		got = append(got, result{issue.Kind, issue.Name, issue.Pos.Line, issue.Category})
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Check:\ngot  %v\nwant %v", got, want)
+	}
+
+	if _, err := Check(fset, []*ast.File{file}, Config{Upgrade: "one.twenty"}); err == nil {
+		t.Errorf("Check with invalid Upgrade: expected error")
+	}
+	if _, err := Check(fset, []*ast.File{file}, Config{Enable: []Kind{Param}, Disable: []Kind{Param}}); err == nil {
+		t.Errorf("Check with a kind both enabled and disabled: expected error")
+	}
+}
+
+func TestSelectorErrors(t *testing.T) {
+	for _, tt := range []struct {
+		cfg Config
+		err string
+	}{
+		{Config{Ignore: []string{"nwe"}}, `-ignore: invalid entry "nwe": "nwe" is not a predeclared identifier (did you mean "new"?)`},
+		{Config{Ignore: []string{"len", "@type"}}, `-ignore: invalid entry "@type": unknown group "@type" (did you mean "@types"?)`},
+		{Config{Only: []string{"parm:new"}}, `-only: invalid entry "parm:new": unknown declaration kind "parm" (did you mean "param"?)`},
+		{Config{Only: []string{"receiver:eror"}}, `-only: invalid entry "receiver:eror": "eror" is not a predeclared identifier (did you mean "error"?)`},
+		{Config{Tests: Policy{Ignore: []string{"cpy"}}}, `-test-ignore: invalid entry "cpy": "cpy" is not a predeclared identifier (did you mean "copy"?)`},
+		{Config{Examples: Policy{Enable: []Kind{LocalVar}, Disable: []Kind{LocalVar}}}, `-example-disable: kind local-variable is both enabled and disabled`},
+	} {
+		_, err := newConfig(tt.cfg, nil)
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("newConfig(%+v): got error %v, want %s", tt.cfg, err, tt.err)
+		}
+	}
+}
+
+// TestConfigFile checks that configuration files are found by walking up
+// from a package's directory, and that nearer files override farther ones.
+func TestConfigFile(t *testing.T) {
+	for _, tt := range []struct {
+		files []string
+		want  []string
+	}{
+		{
+			[]string{"testdata/configfile/a.go"},
+			[]string{"function new"},
+		},
+		{
+			[]string{
+				"testdata/configfile/legacy/a.go",
+				"testdata/configfile/legacy/a_old.go",
+				"testdata/configfile/legacy/gen/b.go",
+			},
+			[]string{"function copy", "function new", "param append", "field cap"},
+		},
+	} {
+		fset := token.NewFileSet()
+		var files []*ast.File
+		for _, path := range tt.files {
+			file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files = append(files, file)
+		}
+		issues, err := Check(fset, files, Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, issue := range issues {
+			got = append(got, issue.Kind.String()+" "+issue.Name)
+		}
+		if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+			t.Errorf("%s: got %q, want %q", tt.files[0], got, tt.want)
+		}
+
+		issues, err = Check(fset, files, Config{NoConfigFile: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(issues) <= len(tt.want) {
+			t.Errorf("%s: NoConfigFile: got %d issues, want more than %d", tt.files[0], len(issues), len(tt.want))
+		}
+	}
+}
+
+// TestKindAliases checks that settings that name the kinds const, variable
+// and param, as they were before they were split into narrower kinds, cover
+// the narrower kinds, and that the narrower kinds take precedence.
+func TestKindAliases(t *testing.T) {
+	const src = `package p
+
+const len = 1
+
+var cap = 2
+
+func f(new int) {
+	const copy = 3
+	var append = 4
+	g := func(close int) {}
+	_, _ = append, g
+}
+
+type T int
+
+func (T) m(string int) {}
+
+type F func(error int)
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	check := func(c Config, configFile string) string {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, ".predeclared.yaml"), []byte("root: true\n"+configFile), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		issues, err := Check(fset, []*ast.File{file}, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, issue := range issues {
+			got = append(got, issue.Name)
+		}
+		return strings.Join(got, " ")
+	}
+
+	var disable kindListFlag
+	if err := disable.Set("param,const"); err != nil {
+		t.Fatal(err)
+	}
+	// ParseKinds expands aliases as the flags do.
+	var parsed []Kind
+	for _, name := range []string{"param", "const"} {
+		kinds, err := ParseKinds(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed = append(parsed, kinds...)
+	}
+	if fmt.Sprint(parsed) != fmt.Sprint(disable) {
+		t.Errorf("ParseKinds: got %v, want %v", parsed, disable)
+	}
+	if k, err := ParseKind("param"); err != nil || k != Param {
+		t.Errorf("ParseKind(%q) = %v, %v, want %v", "param", k, err, Param)
+	}
+	for i, tt := range []struct {
+		config     Config
+		configFile string
+		want       string
+	}{
+		{Config{}, "", "len cap new copy append close string error"},
+		{Config{Disable: disable}, "", "cap append"},
+		{Config{Ignore: []string{"variable:@builtins", "param:new"}}, "", "len copy close string error"},
+		{Config{}, "kinds: {param: false, closure-param: true, variable: false}", "len copy close"},
+		{Config{}, "kinds: {package-variable: false, function-param: false}", "len copy append close string error"},
+	} {
+		if got := check(tt.config, tt.configFile); got != tt.want {
+			t.Errorf("case %d: got %q, want %q", i, got, tt.want)
+		}
+	}
+
+	m := make(renameMap)
+	for _, entry := range []string{"param:new=created", "closure-param:new=fresh"} {
+		if err := m.parseEntry(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for kind, want := range map[Kind]string{Param: "created", MethodParam: "created", ClosureParam: "fresh"} {
+		if got, _ := m.lookup(kind, "new"); got != want {
+			t.Errorf("rename of %s new: got %q, want %q", kind, got, want)
+		}
+	}
+}
+
+func TestConfigFileErrors(t *testing.T) {
+	for _, tt := range []struct {
+		files map[string]string
+		err   string
+	}{
+		{map[string]string{".predeclared.yaml": "preset: loose"}, "unknown preset"},
+		{map[string]string{".predeclared.yaml": "ignored: [new]"}, "not found"},
+		{map[string]string{".predeclared.json": `{"kinds": {"parameter": false}}`}, "unknown declaration kind"},
+		{map[string]string{".predeclared.json": `{"exclude": ["["]}`}, "invalid exclude pattern"},
+		{map[string]string{".predeclared.yaml": "severity: fatal"}, "unknown severity"},
+		{map[string]string{".predeclared.yaml": "severities: {builtin-func: error}"}, `unknown class "builtin-func" (did you mean "builtin-function"?)`},
+		{map[string]string{".predeclared.yaml": "tests: {kinds: {locals: false}}"}, "unknown declaration kind"},
+		{map[string]string{".predeclared.yaml": "", ".predeclared.json": "{}"}, "more than one configuration file"},
+	} {
+		dir := t.TempDir()
+		for name, content := range tt.files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o666); err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, err := loadFileSettings(dir)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%v: got error %v, want error containing %q", tt.files, err, tt.err)
+		}
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	s, err := loadFileSettings("testdata/configfile/legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := newConfig(Config{Ignore: []string{"new"}}, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeEffectiveConfig(&buf, "example.org/legacy", cfg); err != nil {
+		t.Fatal(err)
+	}
+	abs, err := filepath.Abs("testdata/configfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.ReplaceAll(buf.String(), filepath.ToSlash(abs), "$DIR")
+	got = strings.ReplaceAll(got, abs, "$DIR")
+	const want = `# effective configuration for example.org/legacy
+# from $DIR/.predeclared.yaml
+# from $DIR/legacy/.predeclared.json
+ignore:
+  - len
+  - new
+qualified: true
+kinds:
+  closure-param: true
+  embedded-field: true
+  exported-param: false
+  field: true
+  func-type-param: true
+  function: true
+  function-param: true
+  implicit-import-name: true
+  import-name: true
+  interface-method-param: true
+  label: false
+  local-const: false
+  local-variable: false
+  method: true
+  method-param: true
+  named-return: false
+  package-const: true
+  package-name: true
+  package-variable: true
+  range-variable: false
+  receiver: false
+  select-case-variable: false
+  type: true
+  type-parameter: false
+  type-switch-variable: false
+exclude:
+  - $DIR/legacy/gen
+  - $DIR/legacy/*_old.go
+severities:
+  basic-type: warning
+  bool-constant: error
+  builtin-function: warning
+  interface-type: error
+  iota: error
+  zero-value: error
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	for k := PackageName; k <= ExportedParam; k++ {
+		for _, s := range []string{k.String(), k.key()} {
+			got, err := ParseKind(s)
+			if err != nil || got != k {
+				t.Errorf("ParseKind(%q) = %v, %v; want %v", s, got, err, k)
+			}
+		}
+	}
+	if _, err := ParseKind("parameter"); err == nil {
+		t.Errorf("ParseKind(%q): expected error", "parameter")
+	}
+}
+
+// TestNewAnalyzer checks that analyzers created by NewAnalyzer are
+// configured independently of one another.
+func TestNewAnalyzer(t *testing.T) {
+	quiet := NewAnalyzer(Config{Ignore: []string{"len", "new"}})
+	loud := NewAnalyzer(Config{Qualified: true})
+	if err := analysis.Validate([]*analysis.Analyzer{Analyzer, quiet, loud}); err != nil {
+		t.Fatal(err)
+	}
+	analysistest.Run(t, analysistest.TestData(), quiet, "configs/quiet")
+	analysistest.Run(t, analysistest.TestData(), loud, "configs/loud")
+}
+
+// TestMultichecker checks that analyzers created by NewAnalyzer with
+// distinct names can be run together by multichecker, which registers the
+// flags of each under its name.
+func TestMultichecker(t *testing.T) {
+	if os.Getenv("PREDECLARED_MULTICHECKER") == "1" {
+		os.Args = []string{os.Args[0], "-json", "-quiet.ignore=len,new,copy", "./testdata/src/configs/quiet"}
+		multichecker.Main(
+			NewAnalyzer(Config{Name: "quiet", Ignore: []string{"len", "new"}}),
+			NewAnalyzer(Config{Name: "loud", Qualified: true}),
+		)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestMultichecker$")
+	cmd.Env = append(os.Environ(), "PREDECLARED_MULTICHECKER=1")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("multichecker: %v\n%s", err, out)
+	}
+	// The JSON output maps package paths to analyzer names to diagnostics.
+	var tree map[string]map[string][]struct{ Message string }
+	if err := json.Unmarshal(out, &tree); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	counts := make(map[string]int)
+	for _, analyzers := range tree {
+		for name, diags := range analyzers {
+			counts[name] += len(diags)
+		}
+	}
+	if counts["quiet"] != 0 || counts["loud"] != 5 {
+		t.Errorf("got %v diagnostics by analyzer, want none by quiet and 5 by loud:\n%s", counts, out)
+	}
+}
+
+func TestRenameMapErrors(t *testing.T) {
+	for _, s := range []string{
+		"new",
+		"nwe=created",
+		"new=func",
+		"new=len",
+		"new=1x",
+		"field:new=created",
+		"new:len=n",
+	} {
+		if err := (renameMap{}).parseEntry(s); err == nil {
+			t.Errorf("parse(%q): expected error", s)
+		}
+	}
+}
+
+// TestRenameAcrossFiles checks that a fix renaming a package-level
+// declaration also renames its uses in other files, including test files.
+func TestRenameAcrossFiles(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "renames")
+}
+
+// TestRenamePackage checks that fixes rename a package clause in every file
+// of the package, and the references to the package in its importers.
+func TestRenamePackage(t *testing.T) {
+	setFlag(t, FixPackagesFlag, "true")
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "runeuser", "example.org/y/rune")
+}
+
+// TestRenameSuppressedPackage checks that the references to a package whose
+// package clause isn't reported, as a directive suppresses it, aren't renamed
+// either, so that applying the fixes doesn't break the build.
+func TestRenameSuppressedPackage(t *testing.T) {
+	setFlag(t, FixPackagesFlag, "true")
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "runesuppressed", "example.org/z/rune")
+}
+
+// TestPackageNameFact checks that an unaliased import of a package whose
+// name is predeclared is reported in the importer as well as at its source.
+func TestPackageNameFact(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "importer", "example.org/x/string")
+}
+
+// TestPrecisePackageClause checks that precise mode reports the unaliased
+// imports of a package, but not its package clause, which declares nothing
+// in any scope.
+func TestPrecisePackageClause(t *testing.T) {
+	setFlag(t, PreciseFlag, "true")
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "preciseimporter", "example.org/y/string")
+}
+
+// TestResult checks that analyzers that require Analyzer can use its
+// result.
+func TestResult(t *testing.T) {
+	user := &analysis.Analyzer{
+		Name:     "resultuser",
+		Doc:      "report the declarations in the result of the predeclared analyzer",
+		Requires: []*analysis.Analyzer{Analyzer},
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			result := pass.ResultOf[Analyzer].(*Result)
+			for _, d := range result.Decls {
+				if result.Lookup(d.Object) != d {
+					t.Errorf("Lookup(%v) != %v", d.Object, d)
+				}
+				scope := "package scope"
+				if d.Scope != pass.Pkg.Scope() {
+					start, end := pass.Fset.Position(d.Scope.Pos()), pass.Fset.Position(d.Scope.End())
+					scope = fmt.Sprintf("scope %d:%d-%d:%d", start.Line, start.Column, end.Line, end.Column)
+				}
+				pass.Reportf(d.Pos, "%s %s declares %s in %s, hiding %s", d.Kind, d.Name, d.Object, scope, d.Shadowed)
+			}
+			return nil, nil
+		},
+	}
+	analysistest.Run(t, analysistest.TestData(), user, "result")
+}
+
+// TestPackageDirectives checks that directives at the package clause, and
+// package directives, apply to every file of the package.
+func TestPackageDirectives(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "suppress")
+}
+
+// TestAudit checks that stale and unjustified directives are reported, and
+// that fixes delete stale directives.
+func TestAudit(t *testing.T) {
+	setFlag(t, AuditFlag, "true")
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "audit")
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "auditcfg")
+
+	// The directives of generated files, which aren't checked, aren't
+	// audited.
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "auditgen")
+
+	// Under -q, the method new is reported unless ignored.
+	setFlag(t, QualifiedFlag, "true")
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "auditq")
+}
+
+// TestTestPolicy checks the policies for test files, external test packages
+// and examples given by a configuration file.
+func TestTestPolicy(t *testing.T) {
+	results := analysistest.Run(t, analysistest.TestData(), Analyzer, "testpolicy")
+	want := map[string]Severity{
+		"a.go:3":       SeverityWarning,
+		"a_test.go:5":  SeverityWarning,
+		"a_test.go:9":  SeverityWarning,
+		"a_test.go:14": SeverityInfo,
+		"a_test.go:19": SeverityWarning,
+		"x_test.go:5":  SeverityWarning,
+		"x_test.go:9":  SeverityInfo,
+	}
+	for _, result := range results {
+		for _, decl := range result.Result.(*Result).Decls {
+			pos := result.Pass.Fset.Position(decl.Pos)
+			at := fmt.Sprintf("%s:%d", filepath.Base(pos.Filename), pos.Line)
+			if decl.Severity != want[at] {
+				t.Errorf("%s: Severity = %v, want %v", at, decl.Severity, want[at])
+			}
+		}
+	}
+}
+
+func TestParamPolicy(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), NewAnalyzer(Config{SkipBodiless: true}), "bodiless")
+	analysistest.Run(t, analysistest.TestData(), NewAnalyzer(Config{ExportedParams: "separate"}), "exportedparams")
+	analysistest.Run(t, analysistest.TestData(), NewAnalyzer(Config{ReferencedParams: true}), "referencedparams")
+
+	const src = `package p
+
+func Copy(new []byte) {}
+
+func copyBytes(new []byte) {}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := Check(fset, []*ast.File{file}, Config{ExportedParams: "skip", NoConfigFile: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Pos.Line != 5 {
+		t.Errorf("Check with ExportedParams skip: got %v, want the param of copyBytes only", issues)
+	}
+	if _, err := Check(fset, []*ast.File{file}, Config{ExportedParams: "hide"}); err == nil {
+		t.Errorf("Check with invalid ExportedParams: expected error")
+	}
+}
+
+// TestClasses checks the classes of shadowed identifiers, and their default
+// severities.
+func TestClasses(t *testing.T) {
+	results := analysistest.Run(t, analysistest.TestData(), Analyzer, "classes")
+	want := map[string]Class{
+		"nil":   ClassZeroValue,
+		"true":  ClassBoolConstant,
+		"iota":  ClassIota,
+		"int":   ClassBasicType,
+		"error": ClassInterfaceType,
+		"len":   ClassBuiltinFunc,
+	}
+	for _, result := range results {
+		for _, diag := range result.Diagnostics {
+			name := strings.Fields(diag.Message)[2]
+			if got := Class(diag.Category); got != want[name] {
+				t.Errorf("%s: Category = %q, want %q", diag.Message, got, want[name])
+			}
+		}
+	}
+}
+
+// TestRisk checks risk scores, and that -min-risk leaves out the
+// declarations with lower scores.
+func TestRisk(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), NewAnalyzer(Config{MinRisk: 40}), "risk")
+}
+
+// TestRelated checks the related information of diagnostics: the extent of
+// the scope in which a declaration shadows a predeclared identifier, and its
+// references, in every file of the package.
+func TestRelated(t *testing.T) {
+	results := analysistest.Run(t, analysistest.TestData(), Analyzer, "related")
+	var got []string
+	for _, result := range results {
+		fset := result.Pass.Fset
+		for _, diag := range result.Diagnostics {
+			for _, rel := range diag.Related {
+				pos, end := fset.Position(rel.Pos), fset.Position(rel.End)
+				got = append(got, fmt.Sprintf("%s:%d:%d-%d:%d: %s", filepath.Base(pos.Filename), pos.Line, pos.Column, end.Line, end.Column, rel.Message))
+			}
+		}
+	}
+	want := []string{
+		"a.go:9:9-9:12: reference to variable len",
+		"b.go:3:23-3:26: reference to variable len",
+		"a.go:6:2-8:3: scope in which predeclared builtin function copy is shadowed",
+		"a.go:6:19-6:23: reference to local variable copy",
+		"a.go:7:10-7:14: reference to local variable copy",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("related information:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// TestPackageClause checks that a package clause is reported once, at the
+// file with the package doc comment, with the others as related information,
+// and that a directive at any of them suppresses the report.
+func TestPackageClause(t *testing.T) {
+	results := analysistest.Run(t, analysistest.TestData(), Analyzer, "clause")
+	var got []string
+	for _, result := range results {
+		for _, diag := range result.Diagnostics {
+			for _, rel := range diag.Related {
+				got = append(got, filepath.Base(result.Pass.Fset.Position(rel.Pos).Filename)+": "+rel.Message)
+			}
+		}
+	}
+	want := "a.go: package clause len in another file of the package\nc.go: package clause len in another file of the package"
+	if strings.Join(got, "\n") != want {
+		t.Errorf("related information:\n%s\nwant:\n%s", strings.Join(got, "\n"), want)
+	}
+
+	// A directive at any of the package clauses suppresses the report.
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "clausesuppress")
+}
+
+// TestGenerated checks that generated files are neither checked nor edited
+// by fixes.
+func TestGenerated(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "generated")
+}
+
+// TestModuleGoVersion checks that the go directive of a module, which
+// drivers pass on without the "go" prefix, selects the predeclared
+// identifiers.
+func TestModuleGoVersion(t *testing.T) {
+	dir := filepath.Join(analysistest.TestData(), "modules")
+	analysistest.Run(t, filepath.Join(dir, "go120"), Analyzer, ".")
+
+	// Once the module's version has the builtin, a compatible declaration is
+	// an obsolete polyfill, with a fix that deletes it.
+	analysistest.RunWithSuggestedFixes(t, filepath.Join(dir, "go122"), Analyzer, ".")
+	analysistest.RunWithSuggestedFixes(t, filepath.Join(dir, "go122generic"), Analyzer, ".")
+
+	setFlag(t, UpgradeFlag, "1.21")
+	analysistest.Run(t, filepath.Join(dir, "upgrade"), Analyzer, ".")
+}
+
+// TestVendored checks that vendored packages are skipped, as recognized by
+// their import path rather than their directory.
+func TestVendored(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "vendoring/vendor/example.org/v")
+
+	const src = `package p
+
+var len = 1 // want "variable len has same name as predeclared identifier"
+`
+	gopath := filepath.Join(t.TempDir(), "vendor")
+	dir := filepath.Join(gopath, "src", "p")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	analysistest.Run(t, gopath, Analyzer, "p")
+}
+
+func TestPathPatterns(t *testing.T) {
+	for _, tt := range []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"**/testdata/**", "testdata/a.go", true},
+		{"**/testdata/**", "x/testdata/y/a.go", true},
+		{"**/testdata/**", "x/testdatum/a.go", false},
+		{"internal/legacy/**", "internal/legacy/a.go", true},
+		{"internal/legacy/**", "x/internal/legacy/a.go", false},
+		{"*_gen.go", "a_gen.go", true},
+		{"*_gen.go", "x/a_gen.go", false},
+		{"x/[ab].go", "x/b.go", true},
+		{"x/[^ab].go", "x/b.go", false},
+		{"re:_(gen|mock)\\.go$", "x/y_mock.go", true},
+		{"re:_(gen|mock)\\.go$", "x/y.go", false},
+	} {
+		p, err := parsePathPattern(tt.pattern)
+		if err != nil {
+			t.Errorf("parsePathPattern(%q): %v", tt.pattern, err)
+			continue
+		}
+		if got := p.matches(tt.path); got != tt.want {
+			t.Errorf("%q matches %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+	for _, pattern := range []string{"x/[ab.go", "re:(", "x\\"} {
+		if _, err := parsePathPattern(pattern); err == nil {
+			t.Errorf("parsePathPattern(%q): expected error", pattern)
+		}
+	}
+	if !isVendored("x/vendor/example.org/y") || !isVendored("vendor/golang.org/x/net") || isVendored("x/vendors/y") {
+		t.Errorf("isVendored: wrong result")
+	}
+}
+
+func TestExcludesVersion(t *testing.T) {
+	for _, tt := range []struct {
+		expr string
+		v    string
+		want bool
+	}{
+		{"!go1.21", "go1.21", true},
+		{"!go1.22", "go1.21", false},
+		{"!go1.20", "go1.21", true},
+		{"go1.18 && !go1.21", "go1.21", true},
+		{"!go1.21 || linux", "go1.21", false},
+		{"!go1.21 && linux", "go1.21", true},
+		{"!go1.21 || (appengine && !go1.23)", "go1.21", false},
+		{"!go1.21 || go1.19 && !go1.20", "go1.21", true},
+		{"!go1.21 || !go1.23", "go1.21", false},
+		{"linux", "go1.21", false},
+	} {
+		expr, err := constraint.Parse("//go:build " + tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := excludesVersion(expr, tt.v); got != tt.want {
+			t.Errorf("excludesVersion(%q, %s) = %v, want %v", tt.expr, tt.v, got, tt.want)
+		}
+	}
+	if excludesVersion(nil, "go1.21") {
+		t.Errorf("excludesVersion(nil): want false")
+	}
+}
+
+// setFlag sets the named analyzer flag for the duration of the test.
+func setFlag(t *testing.T, name, value string) {
+	f := Analyzer.Flags.Lookup(name)
+	old := f.Value.String()
+	if err := f.Value.Set(value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Value.Set(old) })
+}
+
 func runOneFile(t *testing.T, cfg *config, path string) {
 	src, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -101,7 +865,7 @@ func runOneFile(t *testing.T, cfg *config, path string) {
 	}
 
 	fset := token.NewFileSet()
-	file, err := parser.ParseFile(fset, path, src, parser.AllErrors)
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.AllErrors)
 	if err != nil {
 		t.Errorf("failed to parse file")
 		return
@@ -109,7 +873,7 @@ func runOneFile(t *testing.T, cfg *config, path string) {
 
 	dummyReportFunc := func(analysis.Diagnostic) {}
 
-	issues := processFile(dummyReportFunc, cfg, fset, file)
+	issues := processFile(dummyReportFunc, cfg, fset, file, typeCheck(fset, file))
 	var buf bytes.Buffer
 	for _, issue := range issues {
 		fmt.Fprintf(&buf, "%s\n", issue)
@@ -117,3 +881,90 @@ func runOneFile(t *testing.T, cfg *config, path string) {
 
 	equalBytes(t, outContent, buf.Bytes(), bytes.TrimSpace)
 }
+
+// fakeImporter imports every path as an empty package named after the last
+// element of the path.
+type fakeImporter struct{}
+
+func (fakeImporter) Import(path string) (*types.Package, error) {
+	name := path[strings.LastIndexByte(path, '/')+1:]
+	pkg := types.NewPackage(path, name)
+	pkg.MarkComplete()
+	return pkg, nil
+}
+
+// typeCheck type-checks file as a package of its own. Testdata files needn't
+// be well-typed: type errors are ignored, and the returned info holds
+// whatever the type checker could work out.
+func typeCheck(fset *token.FileSet, file *ast.File) *pkgInfo {
+	info := &types.Info{
+		Defs:      make(map[*ast.Ident]types.Object),
+		Uses:      make(map[*ast.Ident]types.Object),
+		Implicits: make(map[ast.Node]types.Object),
+		Scopes:    make(map[ast.Node]*types.Scope),
+	}
+	conf := types.Config{
+		Importer: fakeImporter{},
+		Error:    func(error) {},
+	}
+	pkg, _ := conf.Check(file.Name.Name, fset, []*ast.File{file}, info)
+	return &pkgInfo{pkg: pkg, info: info}
+}
+
+func goldenPath(p string) string { return strings.TrimSuffix(p, ".go") + ".golden" }
+
+// runOneFixFile applies every fix suggested for the file at path, and
+// compares the result with the file's golden file.
+func runOneFixFile(t *testing.T, cfg *config, path string) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Errorf("failed to read file: %s", err)
+		return
+	}
+
+	golden, err := ioutil.ReadFile(goldenPath(path))
+	if err != nil {
+		t.Errorf("failed to read golden file: %s", err)
+		return
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.AllErrors)
+	if err != nil {
+		t.Errorf("failed to parse file")
+		return
+	}
+
+	var edits []analysis.TextEdit
+	report := func(d analysis.Diagnostic) {
+		for _, fix := range d.SuggestedFixes {
+			edits = append(edits, fix.TextEdits...)
+		}
+	}
+	processFile(report, cfg, fset, file, typeCheck(fset, file))
+
+	got, err := applyEdits(fset, src, edits)
+	if err != nil {
+		t.Errorf("%s: %s", path, err)
+		return
+	}
+	equalBytes(t, golden, got, nil)
+}
+
+// applyEdits applies edits, which must not overlap, to src.
+func applyEdits(fset *token.FileSet, src []byte, edits []analysis.TextEdit) ([]byte, error) {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Pos < edits[j].Pos })
+	var buf bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		start, end := fset.Position(e.Pos).Offset, fset.Position(e.End).Offset
+		if start < last {
+			return nil, fmt.Errorf("overlapping edit at offset %d", start)
+		}
+		buf.Write(src[last:start])
+		buf.Write(e.NewText)
+		last = end
+	}
+	buf.Write(src[last:])
+	return buf.Bytes(), nil
+}
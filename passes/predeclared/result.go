@@ -0,0 +1,64 @@
+package predeclared
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// A Result is the result of the analyzer for a package: the declarations in
+// the package that the analyzer reports, in the order they are reported.
+// Analyzers that list Analyzer, or an analyzer created by NewAnalyzer, in
+// their Requires find it in Pass.ResultOf.
+type Result struct {
+	Decls []*Declaration
+}
+
+// Lookup returns the declaration of obj, or nil if obj isn't among the
+// declarations of r.
+func (r *Result) Lookup(obj types.Object) *Declaration {
+	if obj == nil {
+		return nil
+	}
+	for _, d := range r.Decls {
+		if d.Object == obj {
+			return d
+		}
+	}
+	return nil
+}
+
+// A Declaration is a declaration that has the same name as a predeclared
+// identifier.
+type Declaration struct {
+	Kind Kind
+	// Name is the declared name.
+	Name string
+	// Ident is the declared identifier. It is nil for an ImplicitImportName,
+	// which is declared by an import path rather than an identifier.
+	Ident *ast.Ident
+	// Pos and End give the extent of the declared identifier, or of the
+	// import path for an ImplicitImportName.
+	Pos, End token.Pos
+	// Object is the object declared. It is nil for a PackageName, which
+	// declares no object in its own package, and for declarations that the
+	// type checker couldn't make sense of.
+	Object types.Object
+	// Scope is the scope that Object is declared in. Its Pos and End give the
+	// extent of the declaration's scope, except that a package scope has no
+	// extent: package-level declarations are in scope in every file of the
+	// package. Scope is nil if Object is nil or, as for struct fields and
+	// methods, isn't declared in a scope.
+	Scope *types.Scope
+	// Shadowed is the universe-scope object that has the same name as the
+	// declaration, and which the declaration hides within Scope.
+	Shadowed types.Object
+	// Class is the class of Shadowed.
+	Class Class
+	// Severity is the severity of the declaration's report.
+	Severity Severity
+	// Risk is the risk score of the declaration, from 0 to 100: the higher
+	// the score, the likelier the declaration is to confuse readers, or to
+	// break code that means Shadowed.
+	Risk int
+}
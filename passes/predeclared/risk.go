@@ -0,0 +1,79 @@
+package predeclared
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// Weights of the factors of a risk score, which add up to 100.
+const (
+	// riskBuiltinNearby is the weight of a use of the shadowed universe
+	// object in the function that holds the declaration: code moved, or
+	// written, within the declaration's scope is then likely to mean the
+	// universe object and get the declaration instead. A use elsewhere in
+	// the package counts for riskBuiltinInPackage.
+	riskBuiltinNearby    = 40
+	riskBuiltinInPackage = 15
+	// riskRefs is the weight of the references to the declaration, at
+	// riskPerRef each. The more the declaration is used, the more likely a
+	// reader is to take it for the universe object.
+	riskRefs   = 30
+	riskPerRef = 5
+	// riskScope is the weight of the size of the declaration's scope, at a
+	// point for every riskLinesPerPoint lines. A package-level declaration
+	// scores it in full.
+	riskScope         = 30
+	riskLinesPerPoint = 2
+)
+
+// riskScore returns the risk, from 0 to 100, that the declaration of obj at
+// pos in file, which has the given name, confuses readers or breaks code
+// that means the universe object of that name. obj may be nil, as for the
+// package clause, whose name is in scope in every importing file.
+func riskScore(fset *token.FileSet, file *ast.File, pkg *pkgInfo, obj types.Object, name string, pos token.Pos) int {
+	if obj == nil {
+		return riskScope
+	}
+	universe := types.Universe.Lookup(name)
+	if hiddenUniverseObject(obj) == nil || universe == nil {
+		// Fields, methods and labels never get in the way of the
+		// universe object.
+		return 0
+	}
+	pkg.countRefs()
+
+	var score int
+	// Whether the shadowed universe object is wanted nearby.
+	var fn *ast.FuncDecl
+	for _, decl := range file.Decls {
+		if decl, ok := decl.(*ast.FuncDecl); ok && decl.Pos() <= pos && pos < decl.End() {
+			fn = decl
+		}
+	}
+	for _, ref := range pkg.universeRefs[universe] {
+		if fn != nil && fn.Pos() <= ref && ref < fn.End() {
+			score += riskBuiltinNearby
+			break
+		}
+	}
+	if score == 0 && len(pkg.universeRefs[universe]) > 0 {
+		score += riskBuiltinInPackage
+	}
+
+	// How much the declaration is used.
+	score += min(len(pkg.refs[obj])*riskPerRef, riskRefs)
+
+	// How big its scope is.
+	scope := obj.Parent()
+	switch {
+	case scope == nil:
+	case scope == pkg.pkg.Scope():
+		score += riskScope
+	case scope.End().IsValid():
+		start := max(scope.Pos(), pos)
+		lines := fset.Position(scope.End()).Line - fset.Position(start).Line
+		score += min(lines/riskLinesPerPoint, riskScope)
+	}
+	return score
+}
@@ -0,0 +1,165 @@
+package predeclared
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// groups maps the names of groups of predeclared identifiers, which may stand
+// in for an identifier in -ignore and -only entries, to the category of the
+// identifiers in the group.
+var groups = map[string]Category{
+	"@types":     CategoryType,
+	"@constants": CategoryConstant,
+	"@zero":      CategoryZeroValue,
+	"@builtins":  CategoryBuiltinFunc,
+}
+
+// groupOf returns the name of the group of identifiers of category c.
+func groupOf(c Category) string {
+	for name, category := range groups {
+		if category == c {
+			return name
+		}
+	}
+	return ""
+}
+
+// An identSet is a set of predeclared identifiers, given by entries of the
+// form [kind:]ident or [kind:]@group, as in "new", "param:new",
+// "@builtins", or "receiver:@types". An entry qualified by a kind applies
+// only to declarations of that kind. Entries are stored in normalized form,
+// with kinds written with hyphens for spaces.
+type identSet map[string]struct{}
+
+// add adds entry to s. It returns an error, suggesting the nearest valid
+// name, if the entry names an unknown kind, group, or identifier.
+func (s identSet) add(entry string) error {
+	key, err := normalizeEntry(entry)
+	if err != nil || key == "" {
+		return err
+	}
+	s[key] = struct{}{}
+	return nil
+}
+
+// normalizeEntry returns the normalized form of entry, in which it's stored
+// in an identSet, or the empty string if entry is blank.
+func normalizeEntry(entry string) (string, error) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" {
+		return "", nil
+	}
+	prefix, ident := "", entry
+	if k, rest, ok := strings.Cut(entry, ":"); ok {
+		key, _, err := parseKindKey(k)
+		if err != nil {
+			return "", fmt.Errorf("invalid entry %q: %s", entry, err)
+		}
+		prefix, ident = key+":", rest
+	}
+	if strings.HasPrefix(ident, "@") {
+		if _, ok := groups[ident]; !ok {
+			var names []string
+			for name := range groups {
+				names = append(names, name)
+			}
+			return "", fmt.Errorf("invalid entry %q: unknown group %q%s", entry, ident, suggest(ident, names))
+		}
+	} else if !isPredeclared(ident, "") {
+		return "", fmt.Errorf("invalid entry %q: %q is not a predeclared identifier%s", entry, ident, suggest(ident, predeclaredNames()))
+	}
+	return prefix + ident, nil
+}
+
+// contains reports whether s contains the declaration of name of the given
+// kind.
+func (s identSet) contains(kind Kind, name string) bool {
+	return len(s.matching(kind, name)) > 0
+}
+
+// matching returns the entries of s that contain the declaration of name of
+// the given kind.
+func (s identSet) matching(kind Kind, name string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	kinds := []string{kind.key()}
+	if alias := kind.alias(); alias != "" {
+		kinds = append(kinds, alias)
+	}
+	keys := []string{name}
+	for _, k := range kinds {
+		keys = append(keys, k+":"+name)
+	}
+	if p, ok := predeclaredByName[name]; ok {
+		group := groupOf(p.category)
+		keys = append(keys, group)
+		for _, k := range kinds {
+			keys = append(keys, k+":"+group)
+		}
+	}
+	var entries []string
+	for _, key := range keys {
+		if _, ok := s[key]; ok {
+			entries = append(entries, key)
+		}
+	}
+	return entries
+}
+
+// entries returns the entries of s in sorted order.
+func (s identSet) entries() []string {
+	var entries []string
+	for entry := range s {
+		entries = append(entries, entry)
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+func predeclaredNames() []string {
+	var names []string
+	for _, p := range predeclaredIdents {
+		names = append(names, p.name)
+	}
+	return names
+}
+
+// suggest returns a suggestion of the name among names that is nearest to s,
+// in the form ` (did you mean "name"?)`, or the empty string if names is
+// empty.
+func suggest(s string, names []string) string {
+	best, bestDist := "", -1
+	for _, name := range names {
+		if d := editDistance(s, name); bestDist < 0 || d < bestDist || d == bestDist && name < best {
+			best, bestDist = name, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,162 @@
+package predeclared
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity is the severity of a report. The zero Severity is unset, and
+// stands for the severity that applies otherwise.
+type Severity int
+
+// Severities, from least to most severe.
+const (
+	SeverityInfo Severity = iota + 1
+	SeverityWarning
+	SeverityError
+)
+
+var severityNames = [...]string{
+	SeverityInfo:    "info",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+}
+
+// String returns the name of s, e.g. "warning".
+func (s Severity) String() string {
+	if s <= 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// ParseSeverity returns the Severity with the given name.
+func ParseSeverity(s string) (Severity, error) {
+	for sev := Severity(1); int(sev) < len(severityNames); sev++ {
+		if s == sev.String() {
+			return sev, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity %q (want %s)", s, strings.Join(severityNames[1:], ", "))
+}
+
+// severityMapFlag is a flag.Value for a comma-separated list of
+// class=severity pairs.
+type severityMapFlag map[Class]Severity
+
+func (f *severityMapFlag) String() string {
+	var pairs []string
+	for _, c := range classes {
+		if sev, ok := (*f)[c.class]; ok {
+			pairs = append(pairs, string(c.class)+"="+sev.String())
+		}
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f *severityMapFlag) Set(s string) error {
+	m := make(map[Class]Severity)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid entry %q: want class=severity", pair)
+		}
+		class, err := parseClass(strings.TrimSpace(k))
+		if err != nil {
+			return err
+		}
+		sev, err := ParseSeverity(strings.TrimSpace(v))
+		if err != nil {
+			return err
+		}
+		m[class] = sev
+	}
+	*f = m
+	return nil
+}
+
+// severityFlag is a flag.Value for a Severity.
+type severityFlag Severity
+
+func (f *severityFlag) String() string {
+	if *f == 0 {
+		return ""
+	}
+	return Severity(*f).String()
+}
+
+func (f *severityFlag) Set(s string) error {
+	sev, err := ParseSeverity(s)
+	if err != nil {
+		return err
+	}
+	*f = severityFlag(sev)
+	return nil
+}
+
+// A Class is a class of universe objects that declarations may shadow. It is
+// recorded in the Category of diagnostics, and determines their default
+// severity.
+type Class string
+
+// Classes of shadowed universe objects.
+const (
+	ClassZeroValue     Class = "zero-value"       // nil
+	ClassBoolConstant  Class = "bool-constant"    // true, false
+	ClassIota          Class = "iota"             // iota
+	ClassBasicType     Class = "basic-type"       // e.g. int, string, byte
+	ClassInterfaceType Class = "interface-type"   // error, any, comparable
+	ClassBuiltinFunc   Class = "builtin-function" // e.g. len, append
+)
+
+// classes lists the classes, with their default severities. Shadowing nil,
+// a boolean constant, iota or one of the interface types is an error, as code
+// that means the universe object may silently mean the declaration instead.
+// Shadowing a basic type or a builtin function is a warning: such mistakes
+// mostly fail to compile.
+var classes = []struct {
+	class    Class
+	severity Severity
+}{
+	{ClassZeroValue, SeverityError},
+	{ClassBoolConstant, SeverityError},
+	{ClassIota, SeverityError},
+	{ClassBasicType, SeverityWarning},
+	{ClassInterfaceType, SeverityError},
+	{ClassBuiltinFunc, SeverityWarning},
+}
+
+// classOf returns the class of the predeclared identifier p.
+func classOf(p predeclaredIdent) Class {
+	switch p.category {
+	case CategoryType:
+		switch p.name {
+		case "error", "any", "comparable":
+			return ClassInterfaceType
+		}
+		return ClassBasicType
+	case CategoryConstant:
+		if p.name == "iota" {
+			return ClassIota
+		}
+		return ClassBoolConstant
+	case CategoryZeroValue:
+		return ClassZeroValue
+	}
+	return ClassBuiltinFunc
+}
+
+// parseClass returns the Class with the given name.
+func parseClass(s string) (Class, error) {
+	var names []string
+	for _, c := range classes {
+		if s == string(c.class) {
+			return c.class, nil
+		}
+		names = append(names, string(c.class))
+	}
+	return "", fmt.Errorf("unknown class %q%s", s, suggest(s, names))
+}
@@ -0,0 +1,7 @@
+package configfile
+
+func copy() {}
+
+func new(len int) {}
+
+type T struct{ cap int }
@@ -0,0 +1,7 @@
+package legacy
+
+func copy() {}
+
+func new(len, append int) {}
+
+type T struct{ cap int }
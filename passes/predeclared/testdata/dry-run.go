@@ -0,0 +1,12 @@
+//predeclared -dry-run -fix-package-names
+
+package rune
+
+import cap "foo/capacity"
+
+func f(len int) int { return len + cap.Max }
+
+func g() {
+	var new = 1
+	_ = new
+}
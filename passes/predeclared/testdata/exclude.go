@@ -0,0 +1,5 @@
+//predeclared -exclude=**/testdata/exclude.go
+
+package exclude
+
+var len = 1
@@ -0,0 +1,14 @@
+package foo
+
+import (
+	cap "foo/capacity"
+	false "example.org/f"
+	len "example.org/x/len"
+	new "example.org/n/v2"
+)
+
+var f = false.Value
+
+func size() int {
+	return cap.Of(len.Value) + new.Zero
+}
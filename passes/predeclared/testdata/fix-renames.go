@@ -0,0 +1,33 @@
+//predeclared -rename string=str,param:new=newVal,receiver:error=e -rename-file testdata/renames.txt
+
+package foo
+
+func NewT(new int) *T {
+	return &T{n: new}
+}
+
+func build() *T {
+	new := &T{}
+	return new
+}
+
+func (error *T) Error() string {
+	return error.msg
+}
+
+func lengths(xs []string, length int) int {
+	len := len(xs)
+	for _, string := range xs {
+		_ = string
+	}
+	for copy := range xs {
+		_ = copy
+	}
+	return len + length
+}
+
+func alloc() {
+	created := 1
+	new := created
+	_ = new
+}
@@ -0,0 +1,64 @@
+package foo
+
+import "strings"
+
+type int struct{}
+
+type bool struct{}
+
+type S struct {
+	bool
+}
+
+var n = 3
+
+func upper(string string) (error error) {
+	s := strings.ToUpper(string)
+	_ = s
+	return error
+}
+
+func count(m map[string]T) {
+	len := len(m)
+	println(len, n)
+	for new, copy := range m {
+		_, _ = new, copy
+	}
+}
+
+func (error *T) Len() uint {
+	var cp = 1
+	_ = cp
+	return error.n
+}
+
+func kind(v interface{}) {
+	switch any := v.(type) {
+	case int:
+		_ = any
+	case string:
+		_ = any
+	}
+}
+
+type Set[string comparable] map[string]struct{}
+
+func (s Set[string]) Has(v string) bool {
+	_, ok := s[v]
+	return ok
+}
+
+func lengths(xs [][]int) {
+	var n, n2 int
+	for _, cap := range xs {
+		_ = cap
+	}
+	for i := range xs {
+		len := i
+		_ = len + n + n2
+	}
+}
+
+func caps(c, capacity int, cap []int) int {
+	return c + capacity + len(cap)
+}
@@ -0,0 +1,47 @@
+//predeclared -q
+
+package foo
+
+func F[any comparable](x any) {}
+
+func G[K comparable, len any](m map[K]len) {}
+
+type Set[string comparable] map[string]struct{}
+
+func (s Set[string]) Has(v string) bool {
+	_, ok := s[v]
+	return ok
+}
+
+type Pair[K comparable, V any] struct{}
+
+func (p *Pair[int, V]) Get(k int) (v V) { return }
+
+func (p *Pair[K, cap]) Len() cap { return 0 }
+
+func (Pair[_, _]) Nothing() {}
+
+type Number interface {
+	~int | ~int64 | float64
+}
+
+type Container[T any] interface {
+	~[]T | ~map[int]T
+	len() int
+	append(T)
+}
+
+type Lenner interface {
+	Number
+	print(string) error
+}
+
+func H[T interface {
+	~int | string
+	new() T
+}](t T) {
+}
+
+var fn = func() {
+	_ = func(make int) {}
+}
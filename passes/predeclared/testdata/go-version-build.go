@@ -0,0 +1,9 @@
+//predeclared -go 1.20
+
+//go:build go1.21 && !windows
+
+package foo
+
+func min(a, b int) int { return a }
+
+func max[T int | float64](a, b T) T { return b }
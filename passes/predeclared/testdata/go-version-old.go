@@ -0,0 +1,9 @@
+//predeclared -go 1.17
+
+package foo
+
+type any = interface{}
+
+type comparable interface{}
+
+func new() {}
@@ -0,0 +1,13 @@
+//predeclared -go 1.20.3
+
+package foo
+
+func min(a, b int) int { return a }
+
+func max(a, b int) int { return b }
+
+var clear = true
+
+type any = interface{}
+
+func F(len int) {}
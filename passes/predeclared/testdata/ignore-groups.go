@@ -0,0 +1,17 @@
+//predeclared -ignore=@constants,method-param:new,receiver:@types
+
+package groups
+
+const true = 1
+
+var iota = 2
+
+type T struct{}
+
+func (error T) new(new int, len int) (cap int) {
+	var string = "s"
+	_ = string
+	return 0
+}
+
+func nil() {}
@@ -0,0 +1,10 @@
+package foo
+
+import (
+	_ "example.org/blank/cap"
+	. "example.org/dot/new"
+	"example.org/x/string"
+	okay "example.org/y/len"
+	"example.org/z/print"
+	"fmt"
+)
@@ -0,0 +1,20 @@
+//predeclared -enable=embedded-field -disable=func-type-param,package-variable,local-const
+
+package kinds
+
+const len = 1
+
+var cap = 2
+
+type S struct {
+	error
+	print int
+}
+
+type F func(int int)
+
+func f(string int) {
+	const append = 3
+	var copy int
+	_ = copy
+}
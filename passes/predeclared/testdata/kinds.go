@@ -0,0 +1,31 @@
+//predeclared -q
+
+package kinds
+
+import "io"
+
+const len = 1
+
+var cap = 2
+
+type S struct {
+	error
+	*io.Reader
+	io.Writer
+	*string
+}
+
+type F func(int int)
+
+func f(ch chan int) {
+	const append = 3
+	var copy int
+	g := func(delete int) {}
+	select {
+	case close := <-ch:
+		_ = close
+	case new, ok := <-ch:
+		_, _ = new, ok
+	}
+	_, _ = copy, g
+}
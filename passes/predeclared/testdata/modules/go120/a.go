@@ -0,0 +1,11 @@
+package a
+
+// min isn't predeclared until go1.21.
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func f(len int) int { return min(len, 1) } // want "param len has same name as predeclared identifier"
@@ -0,0 +1,3 @@
+module example.org/go120
+
+go 1.20
@@ -0,0 +1,11 @@
+package a
+
+// min returns the smaller of a and b.
+func min(a, b int) int { // want `function min has same name as predeclared identifier \(obsolete polyfill of the builtin function added in go1.21\)`
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func f(x int) int { return min(x, 1) }
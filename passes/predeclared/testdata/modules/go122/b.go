@@ -0,0 +1,21 @@
+package a
+
+type Buf struct{ data []byte }
+
+// clear takes a pointer, which the builtin doesn't.
+func clear(b *Buf) { b.data = b.data[:0] } // want `function clear has same name as predeclared identifier$`
+
+type P struct{ x, y int }
+
+// max takes a struct, which isn't ordered.
+func max(a, b P) P { // want `function max has same name as predeclared identifier$`
+	if a.x > b.x {
+		return a
+	}
+	return b
+}
+
+func g(b *Buf, p P) P {
+	clear(b)
+	return max(p, p)
+}
@@ -0,0 +1,3 @@
+module example.org/go122
+
+go 1.22
@@ -0,0 +1,23 @@
+package a
+
+type number interface {
+	~int | ~float64
+}
+
+func max[T number](a, b T) T { // want `obsolete polyfill`
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func clear[M ~map[string]int](m M) { // want `obsolete polyfill`
+	for k := range m {
+		delete(m, k)
+	}
+}
+
+func f(m map[string]int) int {
+	clear(m)
+	return max(m["a"], m["b"])
+}
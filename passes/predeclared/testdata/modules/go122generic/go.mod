@@ -0,0 +1,3 @@
+module example.org/go122generic
+
+go 1.22
@@ -0,0 +1,10 @@
+package a
+
+func min(a, b int) int { // want "function min will shadow predeclared builtin function min in go1.21"
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func f(len int) int { return min(len, 1) }
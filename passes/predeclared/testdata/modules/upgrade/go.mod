@@ -0,0 +1,3 @@
+module example.org/upgrade
+
+go 1.20
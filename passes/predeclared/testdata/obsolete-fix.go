@@ -0,0 +1,39 @@
+//predeclared -go 1.22
+
+package foo
+
+// min returns the smaller of a and b.
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(xs ...int) int {
+	m := xs[0]
+	for _, x := range xs {
+		if x > m {
+			m = x
+		}
+	}
+	return m
+}
+
+func clear(m map[string]int) {
+	for k := range m {
+		delete(m, k)
+	}
+}
+
+type (
+	// any is the empty interface.
+	any = interface{}
+
+	value any
+)
+
+func f(xs []int, m map[string]int) (int, any) {
+	clear(m)
+	return min(xs[0], xs[1]) + max(xs...), value(nil)
+}
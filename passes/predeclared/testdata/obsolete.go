@@ -0,0 +1,31 @@
+//predeclared -go 1.22
+
+package foo
+
+type ordered interface {
+	~int | ~float64 | ~string
+}
+
+func min[T ordered](x, y T) T {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func clear(m map[string]int) {
+	for k := range m {
+		delete(m, k)
+	}
+}
+
+type any = interface{}
+
+func abs(x int) int { return max(x, -x) }
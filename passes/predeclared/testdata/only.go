@@ -0,0 +1,13 @@
+//predeclared -only=@zero,len,param:@types
+
+package only
+
+var len = 1
+
+func nil() {}
+
+func f(int int, cap int) (string string) {
+	var float64, copy = 1, 2
+	_, _ = float64, copy
+	return ""
+}
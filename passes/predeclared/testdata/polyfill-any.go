@@ -0,0 +1,7 @@
+//predeclared -go 1.22
+
+//go:build !go1.18 && (appengine || !windows)
+
+package foo
+
+type any = interface{}
@@ -0,0 +1,22 @@
+//predeclared -go 1.20
+
+package foo
+
+// min returns the smaller of a and b.
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func clear(m map[string]int) {
+	for k := range m {
+		delete(m, k)
+	}
+}
+
+func f(m map[string]int) int {
+	clear(m)
+	return min(m["a"], m["b"])
+}
@@ -0,0 +1,7 @@
+//predeclared -go 1.22 -include-polyfills
+
+//go:build !go1.21
+
+package foo
+
+func min(a, b int) int { return a }
@@ -0,0 +1,9 @@
+//predeclared -go 1.22
+
+//go:build !go1.21 || linux
+
+package foo
+
+func min(a, b int) int { return a }
+
+type any interface{}
@@ -0,0 +1,13 @@
+//predeclared -go 1.22
+
+//go:build !go1.21
+
+package foo
+
+func min(a int, b float64) int { return a }
+
+func max() {}
+
+func clear(a, b []int) {}
+
+type any = interface{ M() }
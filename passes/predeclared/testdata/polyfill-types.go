@@ -0,0 +1,19 @@
+//predeclared -go 1.22
+
+//go:build !go1.21
+
+package foo
+
+type Buf struct{ data []byte }
+
+func clear(b *Buf) { b.data = b.data[:0] }
+
+type P struct{ x, y int }
+
+func max(a, b P) P { return a }
+
+type number interface {
+	~int | ~float64
+}
+
+func min[T number](a, b T) T { return a }
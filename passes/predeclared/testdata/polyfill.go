@@ -0,0 +1,33 @@
+//predeclared -go 1.22
+
+//go:build !go1.21
+
+package foo
+
+type ordered interface {
+	~int | ~int64 | ~float64 | ~string
+}
+
+func min[T ordered](x T, y ...T) T {
+	for _, v := range y {
+		if v < x {
+			x = v
+		}
+	}
+	return x
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func clear(m map[string]int) {
+	for k := range m {
+		delete(m, k)
+	}
+}
+
+func f(len int) {}
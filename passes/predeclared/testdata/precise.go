@@ -0,0 +1,37 @@
+//predeclared -q -precise
+
+package rune
+
+import false "example.org/f"
+
+type T struct {
+	print func(float64 F) (float32 F)
+	int
+}
+
+type I interface {
+	uintptr(int32 P)
+}
+
+func (error T) complex64(string string) (byte int) {
+	const nil = 0
+	var iota = true
+	len := 1
+imag:
+	for cap := range []T{} {
+		break imag
+	}
+	switch new := interface{}(error).(type) {
+	case int, T:
+		_ = new
+	}
+	switch comparable := interface{}(error).(type) {
+	}
+	return
+}
+
+func append[any comparable]() {}
+
+type Map[K comparable, V any] struct{}
+
+func (m Map[K, min]) Get() {}
@@ -0,0 +1,60 @@
+package foo
+
+func loops(m map[string]int, s []T) {
+	for string, int := range m {
+		_, _ = string, int
+	}
+	for len := range s {
+		_ = len
+	}
+	for _, cap := range s {
+		_ = cap
+	}
+	for i := range s {
+		_ = i
+	}
+outer:
+	for close, delete := range m {
+	inner:
+		for _, copy := range s {
+			for new := range copy.items {
+				if new {
+					break inner
+				}
+				continue outer
+			}
+		}
+		_, _ = close, delete
+	}
+	var byte T
+	for byte = range s {
+	}
+}
+
+func switches(v interface{}) {
+	switch error := v.(type) {
+	case int:
+		_ = error + 1
+	case string, []byte:
+		_ = len(error)
+	default:
+		_ = error
+	}
+	switch x := v.(type) {
+	case T:
+		switch any := x.inner.(type) {
+		case nil:
+		case bool:
+			_ = any
+		}
+	}
+	switch v.(type) {
+	case int:
+	}
+	if real := v; real != nil {
+		switch imag := real.(type) {
+		default:
+			_ = imag
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package audit
+
+var cap = 1 //predeclared:ignore -- mirrors the protocol field
+
+var copy = 2 /* want `directive gives no reason for the suppression \(add "-- reason"\)` */ //predeclared:ignore
+
+var x = 3 /* want "directive suppresses no report" */ //predeclared:ignore -- stale
+
+/* want "directive suppresses no report" */ //predeclared:ignore new -- stale
+var y = 4
+
+// z is documented.
+//
+/* want "directive suppresses no report" */ //predeclared:ignore -- stale
+var z = 5
+
+var w = 6 /* want "directive suppresses no report" */ //nolint:errcheck,predeclared // reason
+
+var v = 7 //nolint
+
+var append = 8 /* want `directive gives no reason for the suppression \(add "// reason"\)` */ //nolint:predeclared
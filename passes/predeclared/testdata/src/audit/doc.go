@@ -0,0 +1,4 @@
+// Package audit is a test package for the audit of directives.
+package audit
+
+/* want "directive suppresses no report" */ //predeclared:package-ignore len -- used by the protocol
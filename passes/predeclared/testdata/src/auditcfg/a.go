@@ -0,0 +1,7 @@
+package auditcfg // want `ignore entry "cap" of .*auditcfg/\.predeclared\.yaml suppresses no report` `ignore entry "function-param:new" of .*auditcfg/\.predeclared\.yaml suppresses no report`
+
+var len = 1
+
+func (T) f(new int) {} // want "method param new has same name as predeclared identifier"
+
+type T int
@@ -0,0 +1,3 @@
+package sub
+
+var copy = 2
@@ -0,0 +1,3 @@
+package testdata
+
+var cap = 3
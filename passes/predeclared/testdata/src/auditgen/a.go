@@ -0,0 +1,3 @@
+package auditgen
+
+var x = 1 /* want "directive suppresses no report" */ //predeclared:ignore -- stale
@@ -0,0 +1,13 @@
+package bodiless
+
+func asm(len int) (cap int)
+
+type Hook func(len int)
+
+type Reader interface {
+	Read(new []byte) (cap int, err error)
+}
+
+func body(len int) {} // want "param len has same name as predeclared identifier"
+
+var f = func(len int) {} // want "closure param len has same name as predeclared identifier"
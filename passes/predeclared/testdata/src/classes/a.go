@@ -0,0 +1,11 @@
+package classes
+
+func f() {
+	nil := 0   // want `^local variable nil has same name as predeclared identifier`
+	true := 1  // want `^local variable true has same name as predeclared identifier`
+	iota := 2  // want `^local variable iota has same name as predeclared identifier`
+	int := 3   // want `^local variable int has same name as predeclared identifier`
+	error := 4 // want `^local variable error has same name as predeclared identifier`
+	len := 5   // want `^local variable len has same name as predeclared identifier`
+	_, _, _, _, _, _ = nil, true, iota, int, error, len
+}
@@ -0,0 +1,3 @@
+package len // want package:"predeclared package name len"
+
+func f() {}
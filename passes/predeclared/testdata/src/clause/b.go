@@ -0,0 +1,2 @@
+// Package len is reported here, where its doc comment is.
+package len // want "package name len has same name as predeclared identifier"
@@ -0,0 +1,2 @@
+//predeclared:ignore -- covers the package clause of every file
+package cap
@@ -0,0 +1,2 @@
+// Package cap would be reported here, but for the directive in a.go.
+package cap
@@ -0,0 +1,12 @@
+package loud
+
+type T struct {
+	len int // want "field len has same name as predeclared identifier"
+}
+
+func (T) new() {} // want "method new has same name as predeclared identifier"
+
+func f(len int) { // want "param len has same name as predeclared identifier"
+	new := len // want "local variable new has same name as predeclared identifier"
+	_ = new
+}
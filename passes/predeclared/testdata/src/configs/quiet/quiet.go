@@ -0,0 +1,13 @@
+package quiet
+
+type T struct {
+	len int
+}
+
+func (T) new() {}
+
+func f(len int) {
+	new := len
+	copy := new // want "local variable copy has same name as predeclared identifier"
+	_ = copy
+}
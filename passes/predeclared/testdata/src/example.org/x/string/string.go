@@ -0,0 +1,3 @@
+package string // want package:"predeclared package name string" `package name string has same name as predeclared identifier`
+
+func Upper(s []byte) []byte { return s }
@@ -0,0 +1 @@
+package rune // want package:"predeclared package name rune" "package name rune has same name as predeclared identifier"
@@ -0,0 +1,3 @@
+package rune
+
+func Count(s string) int { return len(s) }
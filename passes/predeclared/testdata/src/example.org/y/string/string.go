@@ -0,0 +1,3 @@
+package string
+
+func Upper(s []byte) []byte { return s }
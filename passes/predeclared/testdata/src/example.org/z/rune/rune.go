@@ -0,0 +1,4 @@
+//predeclared:ignore -- the package mirrors the rune type
+package rune
+
+func Count(s string) int { return len(s) }
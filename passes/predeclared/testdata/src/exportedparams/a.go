@@ -0,0 +1,21 @@
+package exportedparams
+
+func Copy(dst, new []byte) (len int) { return 0 } // want "exported param new has same name as predeclared identifier" "exported param len has same name as predeclared identifier"
+
+func copyBytes(new []byte) {} // want "param new has same name as predeclared identifier"
+
+type T struct{}
+
+func (T) Append(new int) {} // want "exported param new has same name as predeclared identifier"
+
+type t struct{}
+
+func (t) Append(new int) {} // want "method param new has same name as predeclared identifier"
+
+type Hook func(len int) // want "exported param len has same name as predeclared identifier"
+
+type hook func(len int) // want "func type param len has same name as predeclared identifier"
+
+type Reader interface {
+	Read(new []byte) // want "exported param new has same name as predeclared identifier"
+}
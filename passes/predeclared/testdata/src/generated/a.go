@@ -0,0 +1,7 @@
+package generated
+
+var len = 1 // want "variable len has same name as predeclared identifier"
+
+var cap = 2 // want "variable cap has same name as predeclared identifier"
+
+var _ = cap
@@ -0,0 +1,7 @@
+package importer
+
+import (
+	"example.org/x/string" // want `implicit import name string has same name as predeclared identifier \(also reported at the package clause of example.org/x/string\)`
+)
+
+var _ = string.Upper
@@ -0,0 +1,7 @@
+package preciseimporter
+
+import (
+	"example.org/y/string" // want `implicit import name string shadows predeclared type string$`
+)
+
+var _ = string.Upper
@@ -0,0 +1,16 @@
+package referencedparams
+
+func f(len int, cap int) int { // want "param len has same name as predeclared identifier"
+	return len
+}
+
+func g() (new int) { // want "named return new has same name as predeclared identifier"
+	new = 1
+	return
+}
+
+func h() (real int) { return 1 }
+
+var _ = func(copy int) {}
+
+var _ = func(copy int) int { return copy } // want "closure param copy has same name as predeclared identifier"
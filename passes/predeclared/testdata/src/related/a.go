@@ -0,0 +1,10 @@
+package related
+
+var len = 3 // want "variable len has same name as predeclared identifier"
+
+func f(s []int) int {
+	if copy := s[0]; copy > 0 { // want "local variable copy has same name as predeclared identifier"
+		return copy
+	}
+	return len
+}
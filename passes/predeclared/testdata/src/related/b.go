@@ -0,0 +1,3 @@
+package related
+
+func g() int { return len * 2 }
@@ -0,0 +1,5 @@
+package renames
+
+var len = 10 // want "variable len has same name as predeclared identifier"
+
+func double() int { return 2 * len }
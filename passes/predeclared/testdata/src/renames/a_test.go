@@ -0,0 +1,9 @@
+package renames
+
+import "testing"
+
+func TestLen(t *testing.T) {
+	if len != 10 {
+		t.Fatal(len)
+	}
+}
@@ -0,0 +1,3 @@
+package renames
+
+func triple() int { return 3 * len }
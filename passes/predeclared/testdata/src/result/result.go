@@ -0,0 +1,12 @@
+package result
+
+import "strings"
+
+var len = 3 // want `variable len declares var result.len int in package scope, hiding builtin len`
+
+func f(string string) int { // want `param string declares var string string in scope 7:1-12:2, hiding type string`
+	for cap := range len { // want `range variable cap declares var cap int in scope 8:2-10:3, hiding builtin cap`
+		string = strings.Repeat(string, cap)
+	}
+	return 0
+}
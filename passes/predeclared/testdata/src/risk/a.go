@@ -0,0 +1,22 @@
+package risk
+
+// trap wants the len builtin, and shadows it in a nested scope.
+func trap(s []int) int {
+	n := len(s)
+	if n > 0 {
+		len := n - 1 // want `local variable len has same name as predeclared identifier \(risk 46\)`
+		return s[len]
+	}
+	return n
+}
+
+// harmless never wants the len builtin.
+func harmless() int {
+	len := 2
+	return len
+}
+
+// real is used often, and in scope in every file of the package.
+var real = 1.5 // want `variable real has same name as predeclared identifier \(risk 45\)`
+
+func scale(x float64) float64 { return x * real * real * real }
@@ -0,0 +1,7 @@
+package runesuppressed
+
+import (
+	"example.org/z/rune" // want "implicit import name rune has same name as predeclared identifier$"
+)
+
+func A(s string) int { return rune.Count(s) }
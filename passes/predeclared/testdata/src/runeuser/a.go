@@ -0,0 +1,7 @@
+package runeuser
+
+import (
+	"example.org/y/rune" // want "implicit import name rune has same name as predeclared identifier"
+)
+
+func A(s string) int { return rune.Count(s) + rune.Count(s) }
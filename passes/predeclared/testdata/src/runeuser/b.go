@@ -0,0 +1,7 @@
+package runeuser
+
+import (
+	"example.org/y/rune" // want "implicit import name rune has same name as predeclared identifier"
+)
+
+func B(yrune string) int { return rune.Count(yrune) }
@@ -0,0 +1,9 @@
+package print
+
+var len = 1
+
+var cap = 2 // want "variable cap has same name as predeclared identifier"
+
+const true = 3
+
+var copy = 4 //predeclared:ignore cpy // want `invalid directive: invalid entry "cpy": "cpy" is not a predeclared identifier \(did you mean "copy"\?\)` "variable copy has same name as predeclared identifier"
@@ -0,0 +1,6 @@
+// Package print is a test package whose name is predeclared.
+//
+//predeclared:ignore -- the name mirrors the builtin
+package print
+
+//predeclared:package-ignore len,@constants -- mirrors the protocol
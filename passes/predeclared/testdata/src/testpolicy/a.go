@@ -0,0 +1,3 @@
+package testpolicy
+
+var copy = 1 // want "variable copy has same name as predeclared identifier"
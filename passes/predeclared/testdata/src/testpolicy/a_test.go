@@ -0,0 +1,21 @@
+package testpolicy
+
+import "testing"
+
+var new = 2 // want "variable new has same name as predeclared identifier"
+
+func TestCopy(t *testing.T) {
+	copy := 1
+	len := 2 // want "local variable len has same name as predeclared identifier"
+	_, _ = copy, len
+}
+
+func ExampleCopy() {
+	string := "" // want "local variable string has same name as predeclared identifier"
+	_ = string
+}
+
+func Benchmarks(b *testing.B) {
+	cap := 1 // want "local variable cap has same name as predeclared identifier"
+	_ = cap
+}
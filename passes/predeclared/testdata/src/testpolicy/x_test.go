@@ -0,0 +1,12 @@
+package testpolicy_test
+
+import "testing"
+
+var real = 1 // want "variable real has same name as predeclared identifier"
+
+func FuzzReal(f *testing.F) {
+	imag := 1
+	for _, cap := range []int{imag} { // want "range variable cap has same name as predeclared identifier"
+		_ = cap
+	}
+}
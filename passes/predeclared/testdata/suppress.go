@@ -0,0 +1,31 @@
+package suppress
+
+//predeclared:file-ignore @zero -- nil is the sentinel in this file
+
+var nil = 0
+
+var len = 1 //predeclared:ignore -- mirrors the protocol field
+
+//predeclared:ignore cap -- mirrors the protocol field
+var cap, copy = 2, 3
+
+var append = 4 //nolint:predeclared // generated
+
+//nolint:errcheck,predeclared
+var new = 5
+
+var make = 6 //nolint:errcheck
+
+//predeclared:ignore param:string -- only params are ignored
+func string(string int) {}
+
+// println is documented.
+//
+// More documentation.
+//
+//predeclared:ignore -- a directive in a doc comment applies to the declaration
+var println = 7
+
+//predeclared:ignore -- separated from the declaration, so doesn't apply
+
+var print = 8
@@ -0,0 +1,16 @@
+//predeclared -go 1.17 -upgrade 1.21
+
+package foo
+
+func min(a, b int) int { return a }
+
+func max(a, b int) int { return b }
+
+func reset(m map[string]int) {
+	clear := func() {}
+	clear()
+	len := len(m)
+	_ = len
+}
+
+type any = interface{}
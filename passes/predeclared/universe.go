@@ -0,0 +1,173 @@
+package predeclared
+
+import (
+	"go/ast"
+	"go/build/constraint"
+	"go/version"
+	"strings"
+)
+
+// Category is the category of a predeclared identifier.
+type Category string
+
+// Categories of predeclared identifiers.
+const (
+	CategoryType        Category = "type"             // e.g. int, error, any
+	CategoryConstant    Category = "constant"         // true, false, iota
+	CategoryZeroValue   Category = "zero value"       // nil
+	CategoryBuiltinFunc Category = "builtin function" // e.g. len, append
+)
+
+// A predeclaredIdent is an identifier that is implicitly declared in the
+// universe block.
+type predeclaredIdent struct {
+	name     string
+	category Category
+	since    string // Go version that added the identifier, e.g. "go1.21"
+}
+
+// predeclaredIdents lists the identifiers of the universe block across Go
+// versions. Identifiers added to the language in a future version should be
+// appended here with that version.
+//
+// https://golang.org/ref/spec#Predeclared_identifiers
+var predeclaredIdents = []predeclaredIdent{
+	{"bool", CategoryType, "go1"},
+	{"byte", CategoryType, "go1"},
+	{"complex64", CategoryType, "go1"},
+	{"complex128", CategoryType, "go1"},
+	{"error", CategoryType, "go1"},
+	{"float32", CategoryType, "go1"},
+	{"float64", CategoryType, "go1"},
+	{"int", CategoryType, "go1"},
+	{"int8", CategoryType, "go1"},
+	{"int16", CategoryType, "go1"},
+	{"int32", CategoryType, "go1"},
+	{"int64", CategoryType, "go1"},
+	{"rune", CategoryType, "go1"},
+	{"string", CategoryType, "go1"},
+	{"uint", CategoryType, "go1"},
+	{"uint8", CategoryType, "go1"},
+	{"uint16", CategoryType, "go1"},
+	{"uint32", CategoryType, "go1"},
+	{"uint64", CategoryType, "go1"},
+	{"uintptr", CategoryType, "go1"},
+	{"any", CategoryType, "go1.18"},
+	{"comparable", CategoryType, "go1.18"},
+
+	{"true", CategoryConstant, "go1"},
+	{"false", CategoryConstant, "go1"},
+	{"iota", CategoryConstant, "go1"},
+
+	{"nil", CategoryZeroValue, "go1"},
+
+	{"append", CategoryBuiltinFunc, "go1"},
+	{"cap", CategoryBuiltinFunc, "go1"},
+	{"close", CategoryBuiltinFunc, "go1"},
+	{"complex", CategoryBuiltinFunc, "go1"},
+	{"copy", CategoryBuiltinFunc, "go1"},
+	{"delete", CategoryBuiltinFunc, "go1"},
+	{"imag", CategoryBuiltinFunc, "go1"},
+	{"len", CategoryBuiltinFunc, "go1"},
+	{"make", CategoryBuiltinFunc, "go1"},
+	{"new", CategoryBuiltinFunc, "go1"},
+	{"panic", CategoryBuiltinFunc, "go1"},
+	{"print", CategoryBuiltinFunc, "go1"},
+	{"println", CategoryBuiltinFunc, "go1"},
+	{"real", CategoryBuiltinFunc, "go1"},
+	{"recover", CategoryBuiltinFunc, "go1"},
+	{"clear", CategoryBuiltinFunc, "go1.21"},
+	{"max", CategoryBuiltinFunc, "go1.21"},
+	{"min", CategoryBuiltinFunc, "go1.21"},
+}
+
+var predeclaredByName = func() map[string]predeclaredIdent {
+	m := make(map[string]predeclaredIdent, len(predeclaredIdents))
+	for _, p := range predeclaredIdents {
+		m[p.name] = p
+	}
+	return m
+}()
+
+// lookupPredeclared returns the predeclared identifier with the given name
+// in the given Go version. An empty version means the latest version known.
+func lookupPredeclared(name, goVersion string) (predeclaredIdent, bool) {
+	p, ok := predeclaredByName[name]
+	if !ok {
+		return predeclaredIdent{}, false
+	}
+	if goVersion != "" && version.Compare(goVersion, p.since) < 0 {
+		return predeclaredIdent{}, false
+	}
+	return p, true
+}
+
+// isPredeclared reports whether name is a predeclared identifier in the given
+// Go version. An empty version means the latest version known.
+func isPredeclared(name, goVersion string) bool {
+	_, ok := lookupPredeclared(name, goVersion)
+	return ok
+}
+
+// normalizeGoVersion returns v, which may be written as "1.21" or "go1.21",
+// in the "go1.21" form used by package go/version. It returns the empty
+// string if v isn't a valid Go version.
+func normalizeGoVersion(v string) string {
+	if v == "" {
+		return ""
+	}
+	if !strings.HasPrefix(v, "go") {
+		v = "go" + v
+	}
+	if !version.IsValid(v) {
+		return ""
+	}
+	return v
+}
+
+// fileGoVersion returns the effective Go language version of file, given the
+// version of its module. Like the type checker, it lets a //go:build line
+// that requires a minimum Go version select the file's version, though never
+// one before go1.21, which introduced that behavior. The empty string means
+// the version is unknown.
+func fileGoVersion(moduleVersion string, file *ast.File) string {
+	v := version.Lang(moduleVersion)
+	if fv := buildConstraintGoVersion(file); fv != "" {
+		if version.Compare(fv, "go1.21") < 0 {
+			fv = "go1.21"
+		}
+		v = fv
+	}
+	return v
+}
+
+// buildConstraintGoVersion returns the minimum Go version required by the
+// //go:build line of file, or the empty string if there is none.
+func buildConstraintGoVersion(file *ast.File) string {
+	expr := buildConstraint(file)
+	if expr == nil {
+		return ""
+	}
+	return constraint.GoVersion(expr)
+}
+
+// buildConstraint returns the parsed //go:build line of file, or nil if there
+// is none. A //go:build line must appear before the package clause.
+func buildConstraint(file *ast.File) constraint.Expr {
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+		for _, c := range group.List {
+			if !constraint.IsGoBuild(c.Text) {
+				continue
+			}
+			expr, err := constraint.Parse(c.Text)
+			if err != nil {
+				return nil
+			}
+			return expr
+		}
+	}
+	return nil
+}
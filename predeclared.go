@@ -13,11 +13,11 @@
 // Flags
 //
 // The '-q' boolean flag, if set, indicates to the command to check struct
-// field names, interface methods, and method names — in addition to the
-// default checks. (These checks aren't included by default since fields and
-// method are always accessed by a qualifier—à la obj.Field—and hence are less
-// likely to cause confusion when reading code even if they have the same name
-// as a predeclared identifier.)
+// field names, embedded fields, interface methods, and method names — in
+// addition to the default checks. (These checks aren't included by default
+// since fields and method are always accessed by a qualifier—à la
+// obj.Field—and hence are less likely to cause confusion when reading code
+// even if they have the same name as a predeclared identifier.)
 //
 // The '-ignore' string flag can be used to specify predeclared identifiers to
 // not report issues for. For example, to not report overriding of the
@@ -25,13 +25,373 @@
 //
 //  -ignore=new,real
 //
+// An entry may instead name a group of identifiers: '@types', '@constants'
+// (true, false, and iota), '@zero' (nil), or '@builtins' (the builtin
+// functions). An entry may also be qualified by a kind of declaration (see
+// '-enable' below), to apply to declarations of that kind only:
+//
+//  -ignore=@constants,param:new,receiver:@types
+//
+// The '-only' string flag takes entries of the same form, and restricts
+// reports to the identifiers that they name. An entry that names no known
+// kind, group, or identifier is an error.
+//
+// The '-enable' and '-disable' string flags take comma-separated lists of
+// kinds of declarations to report, or not, in addition to or instead of the
+// default kinds. For example, to allow the parameters of function types and
+// interface methods, but report embedded fields:
+//
+//  -enable=embedded-field -disable=func-type-param,interface-method-param
+//
+// The kinds are: package-name, import-name, implicit-import-name,
+// package-const and package-variable, local-const and local-variable, type,
+// type-parameter, function, method, field, embedded-field, receiver,
+// function-param (of a function declaration), method-param, closure-param,
+// interface-method-param, func-type-param, named-return, label,
+// range-variable, type-switch-variable, select-case-variable, and
+// exported-param (see '-exported-params' below). Wherever a kind is taken,
+// 'const' stands for package-const and local-const, 'variable' for
+// package-variable, local-variable and select-case-variable, and 'param' for
+// the parameters of every kind of function, as before these kinds were
+// split; a kind named on its own takes precedence over these.
+//
+// Parameter names are often documentation rather than variables. Three
+// flags, which also apply to named returns, set the policy for them. The
+// '-skip-bodiless' boolean flag skips the parameters of functions without a
+// body (eg., those implemented in assembly), of function types, and of
+// interface methods. The '-exported-params' string flag sets the policy for
+// the parameters of exported API: the package-level functions and methods
+// with exported names, and the methods of exported interface types and
+// exported function types. It's 'report' (the default), 'skip', or
+// 'separate', which reports them as the kind 'exported-param', to be enabled,
+// disabled, or ignored on its own. The '-referenced-params' boolean flag
+// reports a parameter only if the function body refers to it. In a
+// configuration file:
+//
+//  params:
+//    skip-bodiless: true
+//    exported: separate
+//    referenced: true
+//
+// The '-precise' boolean flag, if set, indicates to the command to use type
+// information and report a declaration only if it actually hides a
+// universe-scope object within its scope. Labels, struct fields, methods, and
+// package clauses are then never reported (the unaliased imports of a package
+// such as 'package string' are, as they do declare the name in the importing
+// file), and each report names the kind of universe object (type, constant,
+// zero value, or builtin function) that is hidden.
+//
+// Each report comes with related information: the extent of the scope in
+// which the declaration shadows the predeclared identifier, and every
+// reference to the declaration. The references to a package-level
+// declaration span every file of the package, in which the predeclared
+// identifier is unavailable. (The command prints related information with
+// '-json', and editors show it alongside the report.)
+//
+// The '-min-risk' int flag, if set, indicates to the command to report only
+// the declarations with at least the given risk score, from 0 to 100, and to
+// give the score of each report. The score weighs whether the function that
+// holds the declaration wants the shadowed builtin (or, failing that,
+// whether the package does), how many references the declaration has, and
+// how big its scope is. A 'len' variable in a function that also calls len()
+// is a trap; one in a function that never needs the builtin is harmless. For
+// example, to start enforcement with the worst cases:
+//
+//  -min-risk=50
+//
+// The '-upgrade' string flag, if set to a Go version, indicates to the command
+// to report only the declarations that will start to shadow a predeclared
+// identifier once the module's go directive is raised to that version. For
+// example, before moving a module to Go 1.21, which added the 'min', 'max',
+// and 'clear' builtins:
+//
+//  -upgrade=1.21
+//
+// Whether a name is predeclared depends on the Go language version of the
+// file that declares it: the module's go directive, or the minimum version
+// required by the file's //go:build line.
+//
+// A polyfill of a newer predeclared identifier isn't reported: a package-level
+// declaration of min, max, clear, or any, in a file that build constraints
+// restrict to the Go versions before the identifier was added, and that is
+// compatible with the identifier (a min or max function whose parameters and
+// result are of one ordered type, a clear function of one map or slice
+// parameter and no result, or 'type any = interface{}'). For example:
+//
+//  //go:build !go1.21
+//
+//  package compat
+//
+//  func min(a, b int) int { ... }
+//
+// The '-include-polyfills' boolean flag reports them too.
+//
+// Once the Go version of the file has the predeclared identifier, such as
+// after the module's go directive is raised, a compatible declaration that
+// build constraints don't restrict is an obsolete polyfill, which shadows the
+// builtin with subtly different semantics (eg., the handling of NaN by min and
+// max). Obsolete polyfills are reported as such, with a suggested fix that
+// deletes the declaration, so that its uses refer to the predeclared
+// identifier instead. The fix isn't suggested if a use wouldn't compile then,
+// such as a min function used as a value.
+//
+// An import without an alias declares the imported package's own name in the
+// importing file, so imports of a package such as 'package string' are
+// reported too, as is the package clause of such a package (except in precise
+// mode). A package clause is reported once per package: at the file that holds
+// the package doc comment, or else at the first file in order of name, with
+// the package clauses of the other files as related information.
+//
+// Generated files, recognized by the standard '// Code generated ... DO NOT
+// EDIT.' header, and the files of vendored packages, whose import path has a
+// 'vendor' element, are skipped unless the '-include-generated' or
+// '-include-vendored' boolean flag is set. The '-exclude' string flag takes
+// comma-separated patterns of further files to skip. A pattern is a glob, in
+// which '**' matches any number of directories, or a regular expression
+// prefixed by 're:', and is matched against the slash-separated path of the
+// file relative to the working directory:
+//
+//  -exclude=**/testdata/**,internal/legacy/**,re:_mock\.go$
+//
+// Skipped files are neither reported nor edited by fixes: a fix that would
+// edit a skipped file, such as a rename of a declaration that is referred to
+// from generated code, isn't suggested.
+//
+// Configuration files
+//
+// Settings can also be kept in a '.predeclared.yaml' (or '.yml', or '.json')
+// file. For each package, the command looks for such files by walking up from
+// the package's directory, and stops at a file that sets 'root: true'.
+// Settings in nearer files override those in farther ones, so that a subtree
+// can have a policy of its own:
+//
+//  root: true
+//  preset: lenient     # strict, default, or lenient
+//  ignore: [new, len]  # replaces the ignore list of farther files
+//  only: [@builtins]   # as the '-only' flag
+//  qualified: false    # as the '-q' flag
+//  kinds:              # report declarations of these kinds, or not
+//    param: true
+//    named-return: false
+//  exclude: [legacy, "*_gen.go"]
+//
+// A preset sets the 'qualified' and 'kinds' settings that the rest of the file
+// amends: 'strict' reports declarations of every kind, and 'lenient' reports
+// only declarations that are visible outside of a function. Exclude patterns
+// accumulate across files. Each is relative to the directory of its file, and
+// excludes the files or directories that it matches; a pattern without a
+// slash also matches files by their base name.
+//
+// Flags apply on top of configuration files: identifiers given with '-ignore'
+// are ignored in addition. The '-no-config' boolean flag disables
+// configuration files, and the '-print-config' boolean flag prints the
+// effective configuration for each of the given packages instead of checking
+// them:
+//
+//  predeclared -print-config ./legacy
+//
+// Severities
+//
+// Each report has a severity, 'error', 'warning', or 'info', which depends
+// on the class of the predeclared identifier that is shadowed: 'zero-value'
+// (nil), 'bool-constant' (true and false), 'iota', and 'interface-type'
+// (error, any, and comparable) are errors by default, and 'basic-type' and
+// 'builtin-function' are warnings. Each diagnostic's category is the class.
+// The '-severities' string flag, or the 'severities' setting, overrides the
+// default severities, and the '-severity' string flag, or the 'severity'
+// setting, sets the severity of every report:
+//
+//  -severities=builtin-function=error,basic-type=info
+//
+// The '-fail-on' string flag, if set to a severity, reports only the reports
+// of at least that severity as diagnostics, which make the command exit with a
+// non-zero status. The others are printed to standard error, prefixed with
+// their severity, and without fixes. For example, to fail only on the serious
+// classes in CI:
+//
+//  predeclared -fail-on=error ./...
+//
+// Test code
+//
+// Test code can have a policy of its own. A '.predeclared.yaml' file may
+// amend the settings for the '_test.go' files of a package ('tests'), for
+// those of its external test package, such as 'package fmt_test'
+// ('external-tests', on top of 'tests'), and for the Example, Benchmark and
+// Fuzz functions of either ('examples'):
+//
+//  tests:
+//    ignore: [new, copy]  # in addition to the top-level list
+//    kinds:
+//      local-variable: false
+//    severity: warning
+//  examples:
+//    severity: info
+//
+// The same settings can be given with flags, prefixed with 'test-', 'xtest-'
+// or 'example-': '-test-ignore', '-test-enable', '-test-disable', and
+// '-test-severity', and so on.
+//
+// Directives
+//
+// Reports can be suppressed in the code itself with comment directives. Each
+// takes an optional list of entries in the form of '-ignore' entries, and an
+// optional reason after '--':
+//
+//  var len = 1 //predeclared:ignore -- mirrors the protocol field
+//
+//  //predeclared:ignore param:string,cap -- mirrors the protocol
+//  func string(string int, cap int) {}
+//
+// A '//predeclared:ignore' directive applies to the declarations on its line
+// or, if it's on a line of its own, on the line after its comment, so it can
+// be placed in a declaration's doc comment. '//nolint:predeclared' is a
+// synonym. At a package clause, the directive applies to the package clause
+// in every file of the package, as a fix would rename them all. The
+// '//predeclared:file-ignore' directive applies to the whole of its file, and
+// the '//predeclared:package-ignore' directive, which is best kept in doc.go,
+// to every file of the package. Without entries, a directive suppresses the
+// reports of every identifier.
+//
+// The '-audit' boolean flag, if set, indicates to the command to also report
+// the directives that suppress no report, with fixes that delete them, and
+// the directives that give no reason. (A '//nolint' comment that doesn't name
+// the linter is for every linter, and isn't audited; nor are the directives
+// of skipped files.) It also reports the ignore entries of a configuration
+// file that suppress no report in any package under the file's directory.
+// These are reported at the package clause of the package in the file's
+// directory, so an entry of a file in a directory with no package isn't
+// audited. Only the configuration file's entries are audited, not those given
+// with '-ignore'. The entries are judged by the reports of the check itself,
+// with the other flags in effect, or, for the packages in subdirectories, by
+// checking their syntax alone.
+//
+// Fixes
+//
+// Most reports come with a suggested fix that renames the declaration and
+// every reference to it, in every file of the package, to an idiomatic name
+// that doesn't collide with anything in scope (eg., len to n, string to s or
+// str, error to err). Run with the '-fix' flag to apply them.
+//
+// The '-rename' string flag overrides the names that fixes use. Each entry
+// maps a predeclared identifier to a replacement, optionally for one kind of
+// declaration only (kinds containing spaces are written with hyphens):
+//
+//  -rename=new=created,len=length,param:new=newVal,named-return:error=err
+//
+// The '-rename-file' string flag names a file that holds such entries, one per
+// line. Entries given with '-rename' take precedence. If a replacement would
+// collide with another name in scope, the default names are used instead.
+//
+// An import alias is renamed to a name derived from the import path. Renaming
+// a package clause is a change that spans packages, and is suggested only if
+// the '-fix-package-names' boolean flag is set: the fix renames the package
+// clause in every file of the package, and the references to the package in
+// every importing package of the same module. The references are renamed
+// only if the package clause is reported, and not suppressed by a directive,
+// say. Run the command over the whole module (eg., './...') when applying
+// these fixes.
+//
+// The '-dry-run' boolean flag replaces each suggested fix with a summary of
+// the files that it would edit. For example, to preview the renaming of
+// package clauses across a module:
+//
+//  predeclared -fix-package-names -dry-run ./...
+//
 package main
 
 import (
+	"flag"
+	"fmt"
+	"go/token"
+	"os"
+	"sync"
+
 	"github.com/nishanths/predeclared/passes/predeclared"
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/singlechecker"
+	"golang.org/x/tools/go/packages"
 )
 
 func main() {
-	singlechecker.Main(predeclared.Analyzer)
+	var failOn predeclared.Severity
+	flag.Func("fail-on", "report only reports of at least this severity (info, warning or error) as diagnostics, which fail the run, and write the others to standard error", func(s string) (err error) {
+		failOn, err = predeclared.ParseSeverity(s)
+		return err
+	})
+	a := predeclared.Analyzer
+	run := a.Run
+	a.Run = func(pass *analysis.Pass) (interface{}, error) {
+		if failOn == 0 {
+			return run(pass)
+		}
+		return runFailOn(run, pass, failOn)
+	}
+	singlechecker.Main(a)
+}
+
+// warningsMu serializes the writing of warnings by concurrent passes.
+var warningsMu sync.Mutex
+
+// roots holds the import paths of the packages that the command-line
+// patterns denote. The driver runs the analyzer on their dependencies too,
+// for facts, but reports only on these.
+var (
+	rootsOnce sync.Once
+	roots     map[string]bool
+	rootsErr  error
+)
+
+// isRoot reports whether the package with the given import path is one that
+// the command-line patterns denote.
+func isRoot(path string) (bool, error) {
+	rootsOnce.Do(func() {
+		pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName, Tests: true}, flag.Args()...)
+		if err != nil {
+			rootsErr = err
+			return
+		}
+		roots = make(map[string]bool)
+		for _, pkg := range pkgs {
+			roots[pkg.PkgPath] = true
+		}
+	})
+	return roots[path], rootsErr
+}
+
+// runFailOn runs the pass with run, and reports only the reports of at least
+// the severity failOn. The others are written to standard error as warnings,
+// without their fixes, if the package is one that the command-line patterns
+// denote. Reports that aren't of a declaration, such as those of -audit, are
+// always reported.
+func runFailOn(run func(*analysis.Pass) (interface{}, error), pass *analysis.Pass, failOn predeclared.Severity) (interface{}, error) {
+	var diags []analysis.Diagnostic
+	p := *pass
+	p.Report = func(diag analysis.Diagnostic) { diags = append(diags, diag) }
+	result, err := run(&p)
+	if err != nil {
+		return result, err
+	}
+	severities := make(map[token.Pos]predeclared.Severity)
+	if r, ok := result.(*predeclared.Result); ok {
+		for _, decl := range r.Decls {
+			severities[decl.Pos] = decl.Severity
+		}
+	}
+	for _, diag := range diags {
+		if sev, ok := severities[diag.Pos]; ok && sev < failOn {
+			root, err := isRoot(pass.Pkg.Path())
+			if err != nil {
+				return nil, err
+			}
+			if !root {
+				continue
+			}
+			warningsMu.Lock()
+			fmt.Fprintf(os.Stderr, "%s: %s: %s\n", pass.Fset.Position(diag.Pos), sev, diag.Message)
+			warningsMu.Unlock()
+			continue
+		}
+		pass.Report(diag)
+	}
+	return result, nil
 }
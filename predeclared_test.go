@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain runs the command instead of the tests if PREDECLARED_MAIN is set,
+// for the tests to run it in a subprocess.
+func TestMain(m *testing.M) {
+	if os.Getenv("PREDECLARED_MAIN") != "" {
+		os.Args = append([]string{"predeclared"}, strings.Fields(os.Getenv("PREDECLARED_MAIN"))...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// TestFailOn checks that -fail-on writes the reports of lower severities as
+// warnings, and only those of the packages on the command line, not of their
+// dependencies.
+func TestFailOn(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.org/failon\n\ngo 1.22\n",
+		"a.go": `package failon
+
+import "fmt"
+
+func f() {
+	nil := 0
+	len := 1
+	fmt.Println(nil, len)
+}
+`,
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(os.Args[0])
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "PREDECLARED_MAIN=-fail-on=error ./...")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Fatalf("exit: %v, want status 3; stderr:\n%s", err, &stderr)
+	}
+	want := []string{
+		"a.go:7:2: warning: local variable len has same name as predeclared identifier",
+		"a.go:6:2: local variable nil has same name as predeclared identifier",
+	}
+	got := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("stderr:\n%s\nwant %d lines", &stderr, len(want))
+	}
+	for _, w := range want {
+		if !strings.Contains(stderr.String(), filepath.Join(dir, w)) {
+			t.Errorf("stderr:\n%s\nwant line %s", &stderr, w)
+		}
+	}
+}